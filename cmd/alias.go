@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Manage account aliases",
+	Long: `Lists, sets and removes user-defined account aliases.
+An alias can be used anywhere an account name is accepted, e.g. 'steamguard -u main'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		aliases := manifestMgr.GetAliases()
+		if len(aliases) == 0 {
+			fmt.Println("No aliases. Use 'steamguard alias set <alias> <account>' to add one.")
+			return
+		}
+
+		names := make([]string, 0, len(aliases))
+		for alias := range aliases {
+			names = append(names, alias)
+		}
+		sort.Strings(names)
+
+		for _, alias := range names {
+			fmt.Printf("%s -> %s\n", alias, aliases[alias])
+		}
+	},
+}
+
+var aliasSetCmd = &cobra.Command{
+	Use:   "set <alias> <account>",
+	Short: "Point an alias at an account",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		account, err := manifestMgr.GetAccount(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := manifestMgr.SetAlias(args[0], account.AccountName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ %s -> %s\n", args[0], account.AccountName)
+	},
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:   "remove <alias>",
+	Short: "Remove an alias",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := manifestMgr.RemoveAlias(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Alias %s removed\n", args[0])
+	},
+}
+
+var defaultClear bool
+
+var defaultCmd = &cobra.Command{
+	Use:   "default [account]",
+	Short: "Show or set the default account",
+	Long: `Shows or sets the account used when no -u, --tag or --group is given.
+Without a default the first account of the manifest is used.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if defaultClear {
+			if err := manifestMgr.SetDefault(""); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("✓ Default account cleared, the first account will be used")
+			return
+		}
+
+		if len(args) == 0 {
+			account, err := manifestMgr.GetAccount("")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if manifestMgr.GetDefault() == "" {
+				fmt.Printf("%s (first account, no default set)\n", account.AccountName)
+			} else {
+				fmt.Println(account.AccountName)
+			}
+			return
+		}
+
+		account, err := manifestMgr.GetAccount(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := manifestMgr.SetDefault(account.AccountName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Default account: %s\n", account.AccountName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasSetCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+
+	rootCmd.AddCommand(defaultCmd)
+	defaultCmd.Flags().BoolVar(&defaultClear, "clear", false, "Clear the default account")
+}
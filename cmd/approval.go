@@ -0,0 +1,569 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/approval"
+	"github.com/devhooly/steamguard-go/internal/config"
+	"github.com/devhooly/steamguard-go/internal/history"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	operatorKeys    []string
+	operatorKeyFile string
+
+	policyWindow   time.Duration
+	policyAll      bool
+	policyAccounts []string
+	policyTags     []string
+	policyRefresh  bool
+
+	approveNoExecute bool
+)
+
+var operatorCmd = &cobra.Command{
+	Use:   "operator",
+	Short: "Manage operator identities for two-person approval",
+	Long: `Operators are the people allowed to request and approve confirmations on
+protected accounts. Public keys are kept in operators.json next to
+manifest.json; each operator keeps their private key file and passes it
+with --operator-key or STEAMGUARD_OPERATOR_KEY.
+
+operators.json is signed by the operators who changed it and refused when
+it was changed otherwise. Adding or removing operators and changing the
+approval policy needs the key of an existing operator, or the keys of two
+operators while the policy protects any account (repeat --operator-key).
+The first operator signs with their new key.`,
+}
+
+var operatorAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create an operator key pair",
+	Long: `Creates a key pair for an operator. The public key is added to
+operators.json, the private key is written to --key, which must be outside
+the maFiles directory so a copy of maFiles doesn't carry the keys. Hand the
+key file to the operator and keep it away from the other operators.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := config.GetMaFilesPath()
+		name := args[0]
+
+		cfg, err := approval.LoadConfig(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if _, ok := cfg.Operator(name); ok {
+			fmt.Fprintf(os.Stderr, "Error: operator %s already exists\n", name)
+			os.Exit(1)
+		}
+
+		if operatorKeyFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: --key is required, the path of the new private key outside the maFiles directory")
+			os.Exit(1)
+		}
+		keyPath := checkOperatorKeyPath(operatorKeyFile)
+
+		identity, operator, err := approval.NewIdentity(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// The new operator signs too, the first one alone
+		signers := append(loadOperatorIdentities(), identity)
+		previous := *cfg
+		cfg.Operators = append(cfg.Operators[:len(cfg.Operators):len(cfg.Operators)], operator)
+		if err := cfg.Sign(&previous, signers); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := identity.Save(keyPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := approval.SaveConfig(dir, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Operator %s added, private key: %s\n", name, keyPath)
+	},
+}
+
+var operatorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List operators and the approval policy",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := approval.LoadConfig(config.GetMaFilesPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(cfg.Operators) == 0 {
+			fmt.Println("No operators. Use 'steamguard operator add <name>' to add one.")
+		}
+		for _, operator := range cfg.Operators {
+			fmt.Printf("%s (added %s)\n", operator.Name, operator.AddedAt.Local().Format("2006-01-02"))
+		}
+		fmt.Println()
+		printApprovalPolicy(cfg)
+	},
+}
+
+var operatorRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove an operator",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := config.GetMaFilesPath()
+		cfg, err := approval.LoadConfig(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		previous := *cfg
+		var operators []approval.Operator
+		for _, operator := range cfg.Operators {
+			if !strings.EqualFold(operator.Name, args[0]) {
+				operators = append(operators, operator)
+			}
+		}
+		if len(operators) == len(cfg.Operators) {
+			fmt.Fprintf(os.Stderr, "Error: operator %s not found\n", args[0])
+			os.Exit(1)
+		}
+		cfg.Operators = operators
+		if err := cfg.Sign(&previous, loadOperatorIdentities()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := approval.SaveConfig(dir, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Operator %s removed\n", args[0])
+	},
+}
+
+var approvalCmd = &cobra.Command{
+	Use:   "approval",
+	Short: "Two-person approval of confirmations",
+	Long: `Accepting a confirmation on a protected account needs two operators: one
+requests it, a second distinct operator approves it within the approval
+window. Until then every accept of the confirmation fails, including
+'trade --accept' and the daemon.
+
+  steamguard approval policy --protect-tag high-value --window 30m --operator-key alice.key --operator-key bob.key
+  steamguard -u bot1 approval request 1234567890 --operator-key alice.key
+  steamguard approval approve 3f9a --operator-key bob.key`,
+}
+
+var approvalPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show or change which accounts need approval",
+	Long: `Shows or changes which accounts need approval. Protected tags are resolved
+to accounts when the policy is signed, and those accounts stay protected
+even if the tag is removed from them in steamguard.json. An account tagged
+later is protected too; sign it into the policy with --refresh-tags, which
+also releases accounts the tag was removed from.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := config.GetMaFilesPath()
+		cfg, err := approval.LoadConfig(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		previous := *cfg
+		changed := false
+		if cmd.Flags().Changed("window") {
+			cfg.WindowMinutes = int(policyWindow / time.Minute)
+			changed = true
+		}
+		if cmd.Flags().Changed("all") {
+			cfg.ProtectAll = policyAll
+			changed = true
+		}
+		if cmd.Flags().Changed("protect-account") {
+			cfg.ProtectedAccounts = policyAccounts
+			changed = true
+		}
+		if cmd.Flags().Changed("protect-tag") {
+			cfg.ProtectedTags = policyTags
+			changed = true
+		}
+		if cmd.Flags().Changed("protect-tag") || policyRefresh {
+			cfg.TaggedAccounts = taggedAccounts(cfg)
+			changed = true
+		}
+
+		if changed {
+			if err := cfg.Sign(&previous, loadOperatorIdentities()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := approval.SaveConfig(dir, cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		printApprovalPolicy(cfg)
+	},
+}
+
+var approvalRequestCmd = &cobra.Command{
+	Use:   "request <id>",
+	Short: "Mark a confirmation for acceptance",
+	Long: `Marks a confirmation (confirmation ID or trade offer/listing ID) of the
+selected account for acceptance. It's accepted once a second operator
+approves it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		identity := loadOperatorIdentity()
+		dir := config.GetMaFilesPath()
+
+		cfg, err := approval.LoadConfig(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if _, ok := cfg.Operator(identity.Name); !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown operator %q, see 'steamguard operator add'\n", identity.Name)
+			os.Exit(1)
+		}
+
+		account, err := manifestMgr.GetAccount(username)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		client := newClient()
+		confirmations, err := client.GetConfirmations(account)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get confirmations: %v\n", err)
+			os.Exit(1)
+		}
+		matched := steamapi.ConfirmationFilter{IDs: args}.Apply(confirmations)
+		if len(matched) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: confirmation %s not found on %s\n", args[0], account.AccountName)
+			os.Exit(1)
+		}
+
+		queue, err := approval.LoadQueue(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		request, err := queue.Add(identity, account.AccountName, matched[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := approval.SaveQueue(dir, queue); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Approval request %s: %s\n", request.ID, request.Headline)
+		fmt.Println("A second operator must run 'steamguard approval approve " + request.ID + "'.")
+	},
+}
+
+var approvalListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List approval requests",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := config.GetMaFilesPath()
+		cfg, err := approval.LoadConfig(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		queue, err := approval.LoadQueue(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(queue.Requests) == 0 {
+			fmt.Println("No approval requests.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tACCOUNT\tCONFIRMATION\tREQUESTED BY\tREQUESTED AT\tSTATUS\tHEADLINE")
+		for _, request := range queue.Requests {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", request.ID, request.Account, request.ConfirmationID,
+				request.RequestedBy, request.RequestedAt.Local().Format("2006-01-02 15:04"), requestStatus(cfg, request), request.Headline)
+		}
+		w.Flush()
+	},
+}
+
+var approvalApproveCmd = &cobra.Command{
+	Use:   "approve <request-id>",
+	Short: "Approve a request as the second operator and accept the confirmation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		identity := loadOperatorIdentity()
+		dir := config.GetMaFilesPath()
+
+		cfg, err := approval.LoadConfig(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		queue, err := approval.LoadQueue(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		request, err := queue.Get(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := queue.Approve(identity, request, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := approval.SaveQueue(dir, queue); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Approved %s: %s\n", request.ID, request.Headline)
+
+		if approveNoExecute {
+			return
+		}
+
+		account, err := manifestMgr.GetAccount(request.Account)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		client := newClient()
+		confirmations, err := client.GetConfirmations(account)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get confirmations: %v\n", err)
+			os.Exit(1)
+		}
+		matched := steamapi.ConfirmationFilter{IDs: []string{request.ConfirmationID}}.Apply(confirmations)
+		if len(matched) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: confirmation %s is no longer pending\n", request.ConfirmationID)
+			os.Exit(1)
+		}
+
+		if len(skipBelowFloor(client, account, matched[:1])) == 0 {
+			fmt.Println("Approved, but not accepted.")
+			os.Exit(1)
+		}
+		if steamapi.CheckRisk(matched[0]) != nil && !confirmHighRisk(account, matched[0]) {
+			fmt.Println("Approved, but not accepted.")
+			os.Exit(1)
+		}
+
+		err = client.AcceptConfirmation(account, matched[0])
+		results := []steamapi.ConfirmationResult{{Confirmation: matched[0], Err: err}}
+		detail := fmt.Sprintf("approval %s by %s and %s", request.ID, request.RequestedBy, identity.Name)
+		recordHistory(account, results, "accept", history.SourceManual, detail)
+		notifyResults(account, results, "accept", history.SourceManual, detail)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to accept confirmation: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Accepted: %s\n", matched[0].Description())
+	},
+}
+
+var approvalCancelCmd = &cobra.Command{
+	Use:   "cancel <request-id>",
+	Short: "Cancel an approval request",
+	Long: `Cancels a pending approval request. Any configured operator may cancel
+it; the cancellation is signed with the operator's key.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		identity := loadOperatorIdentity()
+		dir := config.GetMaFilesPath()
+
+		cfg, err := approval.LoadConfig(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		queue, err := approval.LoadQueue(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		request, err := queue.Get(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := queue.Cancel(identity, request, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := approval.SaveQueue(dir, queue); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Cancelled %s\n", request.ID)
+	},
+}
+
+// loadOperatorIdentity loads the key of the operator running the command
+func loadOperatorIdentity() *approval.Identity {
+	identities := loadOperatorIdentities()
+	if len(identities) != 1 {
+		fmt.Fprintln(os.Stderr, "Error: one operator key required, use --operator-key or STEAMGUARD_OPERATOR_KEY")
+		os.Exit(1)
+	}
+	return identities[0]
+}
+
+// loadOperatorIdentities loads the keys of the operators signing a change
+func loadOperatorIdentities() []*approval.Identity {
+	paths := operatorKeys
+	if len(paths) == 0 {
+		if path := os.Getenv("STEAMGUARD_OPERATOR_KEY"); path != "" {
+			paths = []string{path}
+		}
+	}
+
+	var identities []*approval.Identity
+	for _, path := range paths {
+		identity, err := approval.LoadIdentity(checkOperatorKeyPath(path))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		identities = append(identities, identity)
+	}
+	return identities
+}
+
+// checkOperatorKeyPath returns the absolute path of an operator key file,
+// exiting if it's inside the maFiles directory
+func checkOperatorKeyPath(path string) string {
+	keyPath, err := filepath.Abs(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	dir, err := filepath.Abs(config.GetMaFilesPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if isInside(keyPath, dir) || isInside(resolveLinks(keyPath), resolveLinks(dir)) {
+		fmt.Fprintf(os.Stderr, "Error: operator key %s is inside the maFiles directory %s, keep it elsewhere\n", path, dir)
+		os.Exit(1)
+	}
+	return keyPath
+}
+
+// isInside checks if path is dir or below it
+func isInside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveLinks resolves symlinks in the existing part of a path
+func resolveLinks(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if parent := filepath.Dir(path); parent != path {
+		return filepath.Join(resolveLinks(parent), filepath.Base(path))
+	}
+	return path
+}
+
+// taggedAccounts returns the accounts currently carrying a protected tag,
+// to be signed into the policy
+func taggedAccounts(cfg *approval.Config) []string {
+	var names []string
+	for _, account := range manifestMgr.GetAllAccounts() {
+		if cfg.HasProtectedTag(manifestMgr.GetMetadata(account.AccountName).Tags) {
+			names = append(names, account.AccountName)
+		}
+	}
+	return names
+}
+
+// requestStatus describes the state of a request
+func requestStatus(cfg *approval.Config, request *approval.Request) string {
+	if request.CheckCancellation(cfg) != nil {
+		return "cancelled (unsigned)"
+	}
+	if request.Status != approval.StatusPending {
+		return request.Status
+	}
+	if request.Expired(cfg, time.Now()) {
+		return "expired"
+	}
+	if request.Check(cfg, time.Now()) == nil {
+		return "approved"
+	}
+	return "waiting"
+}
+
+// printApprovalPolicy prints which accounts need approval
+func printApprovalPolicy(cfg *approval.Config) {
+	fmt.Printf("Approval window: %s\n", cfg.Window())
+	switch {
+	case cfg.ProtectAll:
+		fmt.Println("Protected: all accounts")
+	case len(cfg.ProtectedAccounts) == 0 && len(cfg.ProtectedTags) == 0:
+		fmt.Println("Protected: none")
+	default:
+		if len(cfg.ProtectedAccounts) > 0 {
+			fmt.Printf("Protected accounts: %s\n", strings.Join(cfg.ProtectedAccounts, ", "))
+		}
+		if len(cfg.ProtectedTags) > 0 {
+			fmt.Printf("Protected tags: %s\n", strings.Join(cfg.ProtectedTags, ", "))
+			fmt.Printf("Accounts signed as tagged: %s\n", strings.Join(cfg.TaggedAccounts, ", "))
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(operatorCmd)
+	operatorCmd.AddCommand(operatorAddCmd)
+	operatorCmd.AddCommand(operatorListCmd)
+	operatorCmd.AddCommand(operatorRemoveCmd)
+	operatorCmd.PersistentFlags().StringArrayVar(&operatorKeys, "operator-key", nil, "Private key file of an operator signing the change, repeatable (default: $STEAMGUARD_OPERATOR_KEY)")
+	operatorAddCmd.Flags().StringVar(&operatorKeyFile, "key", "", "Where to write the private key, outside the maFiles directory (required)")
+
+	rootCmd.AddCommand(approvalCmd)
+	approvalCmd.AddCommand(approvalPolicyCmd)
+	approvalCmd.AddCommand(approvalRequestCmd)
+	approvalCmd.AddCommand(approvalListCmd)
+	approvalCmd.AddCommand(approvalApproveCmd)
+	approvalCmd.AddCommand(approvalCancelCmd)
+	approvalCmd.PersistentFlags().StringArrayVar(&operatorKeys, "operator-key", nil, "Private key file of the operator, repeat to sign policy changes (default: $STEAMGUARD_OPERATOR_KEY)")
+
+	approvalPolicyCmd.Flags().DurationVar(&policyWindow, "window", approval.DefaultWindow, "Time a second operator has to approve")
+	approvalPolicyCmd.Flags().BoolVar(&policyAll, "all", false, "Require approval on every account")
+	approvalPolicyCmd.Flags().StringSliceVar(&policyAccounts, "protect-account", nil, "Accounts requiring approval (replaces the list)")
+	approvalPolicyCmd.Flags().StringSliceVar(&policyTags, "protect-tag", nil, "Account tags requiring approval (replaces the list)")
+	approvalPolicyCmd.Flags().BoolVar(&policyRefresh, "refresh-tags", false, "Sign the accounts currently carrying a protected tag into the policy")
+
+	approvalApproveCmd.Flags().BoolVar(&approveNoExecute, "no-execute", false, "Only approve, accept later with 'trade accept'")
+}
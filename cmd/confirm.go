@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/market"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of confirm besides 0 (accepted) and 1 (error)
+const (
+	// confirmExitTimeout the confirmation didn't show up in time
+	confirmExitTimeout = 2
+	// confirmExitRefused a trade hold, price floor or missing approval kept it from being accepted
+	confirmExitRefused = 3
+)
+
+var (
+	confirmOffer     string
+	confirmListing   string
+	confirmTimeout   time.Duration
+	confirmInterval  time.Duration
+	confirmAllowHold bool
+)
+
+var confirmCmd = &cobra.Command{
+	Use:   "confirm",
+	Short: "Wait for the confirmation of a trade offer or listing and accept it",
+	Long: `Polls the pending confirmations until the one created by the given trade
+offer or market listing shows up, and accepts exactly that one. Meant as
+the confirmation step of bots that create offers or listings.
+
+Trades that would be held are refused unless --allow-hold is given, and
+listings below their price floor are refused when floors are set.
+
+Exit codes:
+  0  the confirmation was accepted
+  1  error, e.g. Steam refused the confirmation
+  2  the confirmation didn't show up within --timeout
+  3  refused because of a trade hold, price floor or missing approval
+
+Examples:
+  steamguard -u bot1 confirm --offer 5566778899
+  steamguard -u bot1 confirm --listing 4433221100 --timeout 2m`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if (confirmOffer == "") == (confirmListing == "") {
+			fmt.Fprintln(os.Stderr, "Error: give either --offer or --listing")
+			os.Exit(1)
+		}
+		if confirmInterval <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+			os.Exit(1)
+		}
+
+		account, client := offerAccount()
+
+		creatorID, confType := confirmOffer, steamapi.ConfTypeTrade
+		if confirmListing != "" {
+			creatorID, confType = confirmListing, steamapi.ConfTypeMarketListing
+		}
+
+		conf, err := client.WaitForConfirmation(account, creatorID, confirmTimeout, confirmInterval)
+		if errors.Is(err, steamapi.ErrConfirmationTimeout) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(confirmExitTimeout)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if conf.Type != confType {
+			fmt.Fprintf(os.Stderr, "Error: confirmation %s of %s is a %s confirmation\n", conf.ID, creatorID, conf.TypeName)
+			os.Exit(1)
+		}
+
+		if err := checkConfirmable(client, account, conf); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(confirmExitRefused)
+		}
+
+		results := client.AcceptConfirmations(account, []*steamapi.Confirmation{conf})
+		if printConfirmationResults(client, account, results, true) > 0 {
+			if errors.Is(results[0].Err, steamapi.ErrApprovalRequired) {
+				os.Exit(confirmExitRefused)
+			}
+			os.Exit(1)
+		}
+	},
+}
+
+// checkConfirmable applies the trade hold and price floor checks to the confirmation
+func checkConfirmable(client *steamapi.Client, account *manifest.SteamGuardAccount, conf *steamapi.Confirmation) error {
+	switch conf.Type {
+	case steamapi.ConfTypeTrade:
+		if confirmAllowHold {
+			return nil
+		}
+		attachTradeOffers(client, account, []*steamapi.Confirmation{conf})
+		if err := steamapi.CheckTradeHold(conf); err != nil {
+			return fmt.Errorf("%w (use --allow-hold to accept anyway)", err)
+		}
+	case steamapi.ConfTypeMarketListing:
+		details := func() (*steamapi.ConfirmationDetails, error) {
+			return client.GetConfirmationDetails(account, conf)
+		}
+		return market.CheckFloor(account.AccountName, conf, details, manifestMgr.GetPriceFloors())
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(confirmCmd)
+
+	confirmCmd.Flags().StringVar(&confirmOffer, "offer", "", "Trade offer ID")
+	confirmCmd.Flags().StringVar(&confirmListing, "listing", "", "Market listing ID")
+	confirmCmd.Flags().DurationVar(&confirmTimeout, "timeout", time.Minute, "How long to wait for the confirmation")
+	confirmCmd.Flags().DurationVar(&confirmInterval, "interval", 2*time.Second, "Time between polls")
+	confirmCmd.Flags().BoolVar(&confirmAllowHold, "allow-hold", false, "Also accept a trade that would be held")
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/automation"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/rules"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonInterval time.Duration
+	daemonJitter   time.Duration
+	daemonOnce     bool
+	daemonForce    bool
+	daemonDryRun   bool
+	daemonReplay   string
+	allowHold      bool
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Automatically confirm using the manifest's auto-confirm rules",
+	Long: `Polls confirmations of all accounts (or the ones selected with -u, --tag
+or --group) every periodic_checking_interval seconds of manifest.json and
+applies its auto_confirm_trades rules. Every decision is logged to stderr.
+Network and session errors are logged and retried on the next round.
+
+Each rule has an action (accept, reject or ignore) and conditions, see
+'steamguard rules --help'. The first matching rule wins, confirmations
+matching no rule are left alone:
+
+  "periodic_checking": true,
+  "periodic_checking_interval": 60,
+  "auto_confirm_trades": [
+    {"type": "market", "action": "accept"},
+    {"type": "trade", "action": "ignore"}
+  ]
+
+--dry-run fetches confirmations and evaluates the rules but never accepts
+or rejects. --replay evaluates the rules over confirmations saved with
+'trade --json' without contacting Steam; rules needing confirmation details
+can't be evaluated there.
+
+Before accepting a trade, its hold duration is checked with the partner.
+Trades that would be held, or whose hold can't be determined, are left
+alone unless --allow-hold is given or the matching rule has "allow_hold": true.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if manifestMgr.IsEmpty() {
+			fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
+			return
+		}
+
+		settings := manifestMgr.GetManifest()
+		if daemonReplay != "" {
+			runReplay(settings.AutoConfirm)
+			return
+		}
+		if !settings.PeriodicCheck && !daemonForce && !daemonOnce {
+			fmt.Fprintln(os.Stderr, "Error: periodic checking is disabled in manifest.json (periodic_checking), use --force to run anyway")
+			os.Exit(1)
+		}
+		autoRules, err := rules.Compile(settings.AutoConfirm)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid auto_confirm_trades: %v\n", err)
+			os.Exit(1)
+		}
+
+		accounts := manifestMgr.GetAllAccounts()
+		if username != "" || hasSelector() {
+			if accounts, err = selectAccounts(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		interval := daemonInterval
+		if interval == 0 {
+			interval = time.Duration(settings.PeriodicTime) * time.Second
+		}
+		if interval <= 0 {
+			interval = automation.DefaultInterval
+		}
+		jitter := daemonJitter
+		if jitter < 0 {
+			jitter = interval / 10
+		}
+
+		client := newClient()
+		client.DryRun = daemonDryRun
+		// The daemon reports confirmations itself, and sessions only when renewing them failed
+		client.Observer = nil
+
+		daemon := &automation.Daemon{
+			Manager:   manifestMgr,
+			Client:    client,
+			Logger:    log.New(os.Stderr, "", log.LstdFlags),
+			History:   historyLog(),
+			Notifier:  notifier(),
+			Accounts:  accounts,
+			Rules:     autoRules,
+			Interval:  interval,
+			Jitter:    jitter,
+			AllowHold: allowHold,
+		}
+
+		if daemonDryRun {
+			daemon.Logger.Printf("dry run, nothing will be accepted or rejected")
+		}
+		if len(settings.AutoConfirm) == 0 {
+			daemon.Logger.Printf("no auto_confirm_trades rules, confirmations will only be logged")
+		}
+
+		if daemonOnce {
+			daemon.Poll()
+			return
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := daemon.Run(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// runReplay prints the decisions of the rules for saved confirmations
+func runReplay(defs []manifest.AutoConfirmRule) {
+	autoRules, err := rules.Compile(defs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid auto_confirm_trades: %v\n", err)
+		os.Exit(1)
+	}
+
+	sets, err := automation.LoadReplay(daemonReplay)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	noDetails := func(conf *steamapi.Confirmation) (*steamapi.ConfirmationDetails, error) {
+		return nil, fmt.Errorf("confirmation details are not available when replaying")
+	}
+
+	for _, set := range sets {
+		// Confirmations without an account are replayed as the -u account, if any
+		name := set.Account
+		if name == "" && username != "" {
+			account, err := manifestMgr.GetAccount(username)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			name = account.AccountName
+		}
+
+		label := name
+		if label == "" {
+			label = "-"
+		}
+
+		decisions := automation.Decide(autoRules, name, manifestMgr.GetMetadata(name), set.Confirmations, noDetails, allowHold, manifestMgr.GetPriceFloors())
+		for _, decision := range decisions {
+			conf := decision.Confirmation
+			if decision.Err != nil {
+				fmt.Printf("%s\tignore\t%s\t%s\t%q\t%v\n", label, conf.ID, conf.Type, conf.Description(), decision.Err)
+				continue
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\t%q\t%s\n", label, decision.Result.Action, conf.ID, conf.Type, conf.Description(), decision.Result.Source())
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", 0, "Polling interval (default: periodic_checking_interval of manifest.json)")
+	daemonCmd.Flags().DurationVar(&daemonJitter, "jitter", -1, "Maximum random deviation of the interval (default: 10% of the interval)")
+	daemonCmd.Flags().BoolVar(&daemonOnce, "once", false, "Poll once and exit")
+	daemonCmd.Flags().BoolVar(&daemonForce, "force", false, "Run even if periodic_checking is disabled")
+	daemonCmd.Flags().BoolVar(&daemonDryRun, "dry-run", false, "Evaluate rules but never accept or reject")
+	daemonCmd.Flags().BoolVar(&allowHold, "allow-hold", false, "Accept trades that would be held")
+	daemonCmd.Flags().StringVar(&daemonReplay, "replay", "", "Evaluate rules over confirmations saved with 'trade --json' and exit")
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devhooly/steamguard-go/internal/config"
+	"github.com/devhooly/steamguard-go/internal/doctor"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorFix     bool
+	doctorOffline bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Audit the maFiles setup",
+	Long: `Checks the whole maFiles setup: directory and file permissions, manifest
+entries against .maFile files, secrets, SteamID/device ID/URI consistency,
+encryption consistency, session validity and clock drift against Steam.
+
+Use --fix to apply the fixes that are safe to do automatically.`,
+	// The manifest may be broken, so don't load it through the manager
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		maFilesPath := config.GetMaFilesPath()
+		fmt.Printf("Checking %s\n\n", maFilesPath)
+
+		doc := &doctor.Doctor{
+			Path:    maFilesPath,
+			Client:  steamapi.NewClient(),
+			Offline: doctorOffline,
+			Passkey: func() (string, error) {
+				return prompt("Enter password to decrypt: ")
+			},
+		}
+
+		findings, err := doc.Run()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(findings) == 0 {
+			fmt.Println("✓ No problems found.")
+			return
+		}
+
+		remaining, fixable := 0, 0
+		for _, f := range findings {
+			fmt.Printf("%s [%s] %s: %s\n", severityIcon(f.Severity), f.Check, f.Subject, f.Message)
+
+			if f.Fix == nil {
+				if f.Severity == doctor.SeverityError {
+					remaining++
+				}
+				continue
+			}
+			if !doctorFix {
+				fixable++
+				fmt.Printf("    fix available: %s\n", f.FixDescription)
+				if f.Severity == doctor.SeverityError {
+					remaining++
+				}
+				continue
+			}
+
+			if err := f.Fix(); err != nil {
+				fmt.Printf("    ✗ fix failed: %v\n", err)
+				if f.Severity == doctor.SeverityError {
+					remaining++
+				}
+				continue
+			}
+			fmt.Printf("    ✓ fixed: %s\n", f.FixDescription)
+		}
+
+		if fixable > 0 {
+			fmt.Println("\nUse --fix to apply the available fixes.")
+		}
+		if remaining > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// severityIcon returns the marker printed before a finding
+func severityIcon(s doctor.Severity) string {
+	switch s {
+	case doctor.SeverityError:
+		return "✗"
+	case doctor.SeverityWarning:
+		return "⚠️ "
+	default:
+		return "ℹ️ "
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Apply safe fixes")
+	doctorCmd.Flags().BoolVar(&doctorOffline, "offline", false, "Skip checks that contact Steam")
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/config"
+	"github.com/devhooly/steamguard-go/internal/history"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historySince   string
+	historyUntil   string
+	historyTypes   []string
+	historySources []string
+	historyFormat  string
+
+	historyExpectHead string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show accepted and rejected confirmations",
+	Long: `Every accept and reject performed by steamguard is recorded in
+history.jsonl next to manifest.json: account, confirmation, decision source
+(manual, rule or api) and result. Entries are hash-chained, use
+'history verify' to detect edits.
+
+Examples:
+  steamguard history
+  steamguard -u bot1 history --since 2024-05-01 --until 2024-06-01
+  steamguard --group fleet-a history --type market --format csv > market.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := buildHistoryFilter()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		entries, err := historyLog().Query(filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch historyFormat {
+		case "json":
+			if entries == nil {
+				entries = []history.Entry{}
+			}
+			data, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		case "csv":
+			writeHistoryCSV(entries)
+		case "text":
+			if len(entries) == 0 {
+				fmt.Println("No history entries.")
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tACCOUNT\tACTION\tSOURCE\tRESULT\tTYPE\tID\tHEADLINE")
+			for _, e := range entries {
+				result := e.Result
+				if e.Error != "" {
+					result += ": " + e.Error
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					e.Time.Local().Format("2006-01-02 15:04:05"), e.Account, e.Action, e.Source, result, e.Type, e.ConfirmationID, e.Headline)
+			}
+			w.Flush()
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown format %q (text, csv or json)\n", historyFormat)
+			os.Exit(1)
+		}
+	},
+}
+
+var historyVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the history hash chain for edits",
+	Long: `Checks the hash chain of the history and prints its head, the sequence
+number and hash of the last entry. The chain alone doesn't show removed
+last entries or a log rewritten with new hashes: record the head outside
+the maFiles directory and pass it with --expect-head on the next check,
+which fails unless that entry is still in the log unchanged.
+
+Examples:
+  steamguard history verify
+  steamguard history verify --expect-head 42:3f9a...`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var anchor *history.Head
+		if historyExpectHead != "" {
+			head, err := history.ParseHead(historyExpectHead)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			anchor = &head
+		}
+
+		head, err := historyLog().Verify(anchor)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ History was tampered with: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ %d entries, hash chain intact.\n", head.Seq)
+		if head.Seq > 0 {
+			fmt.Printf("Head: %s\n", head)
+		}
+	},
+}
+
+// historyLog returns the history log of the maFiles directory
+func historyLog() *history.Log {
+	return history.Open(filepath.Join(config.GetMaFilesPath(), history.FileName))
+}
+
+// recordHistory records the outcome of accepting or rejecting confirmations
+func recordHistory(account *manifest.SteamGuardAccount, results []steamapi.ConfirmationResult, action, source, detail string) {
+	entries := make([]history.Entry, 0, len(results))
+	for _, result := range results {
+		entries = append(entries, history.NewEntry(account.AccountName, result.Confirmation, action, source, detail, result.Err))
+	}
+	if err := historyLog().Append(entries...); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to record history: %v\n", err)
+	}
+}
+
+// buildHistoryFilter builds the history filter from the account selectors and flags
+func buildHistoryFilter() (history.Filter, error) {
+	filter := history.Filter{Types: historyTypes, Sources: historySources}
+
+	if username != "" || hasSelector() {
+		accounts, err := selectAccounts()
+		if err != nil {
+			// Removed accounts still have history
+			if username == "" {
+				return filter, err
+			}
+			filter.Accounts = []string{username}
+		}
+		for _, account := range accounts {
+			filter.Accounts = append(filter.Accounts, account.AccountName)
+		}
+	}
+
+	var err error
+	if filter.Since, err = parseHistoryTime(historySince, false); err != nil {
+		return filter, fmt.Errorf("invalid --since: %w", err)
+	}
+	if filter.Until, err = parseHistoryTime(historyUntil, true); err != nil {
+		return filter, fmt.Errorf("invalid --until: %w", err)
+	}
+	return filter, nil
+}
+
+// parseHistoryTime parses a date (local midnight) or an RFC 3339 time.
+// An end date includes the whole day.
+func parseHistoryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// writeHistoryCSV writes entries as CSV
+func writeHistoryCSV(entries []history.Entry) {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"seq", "time", "account", "confirmation_id", "creator_id", "type", "headline",
+		"action", "source", "detail", "result", "error", "prev_hash", "hash"})
+	for _, e := range entries {
+		w.Write([]string{strconv.Itoa(e.Seq), e.Time.Format(time.RFC3339), e.Account, e.ConfirmationID, e.CreatorID,
+			e.Type, e.Headline, e.Action, e.Source, e.Detail, e.Result, e.Error, e.PrevHash, e.Hash})
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyVerifyCmd)
+
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Entries from this date (2006-01-02) or time (RFC 3339)")
+	historyCmd.Flags().StringVar(&historyUntil, "until", "", "Entries up to and including this date, or before this time")
+	historyCmd.Flags().StringSliceVar(&historyTypes, "type", nil, "Confirmation types: trade, market, apikey, ...")
+	historyCmd.Flags().StringSliceVar(&historySources, "source", nil, "Decision sources: manual, rule, api")
+	historyCmd.Flags().StringVar(&historyFormat, "format", "text", "Output format: text, csv or json")
+	historyVerifyCmd.Flags().StringVar(&historyExpectHead, "expect-head", "", "Head printed by an earlier verify (<seq>:<hash>) the log must still contain")
+}
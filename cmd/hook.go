@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/config"
+	"github.com/devhooly/steamguard-go/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hookAccounts []string
+	hookEvents   []string
+	hookTimeout  time.Duration
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage commands run on events",
+	Long: `Hooks are executables run whenever an event happens. The event is written
+to the hook's stdin as JSON, the same JSON webhooks receive, and its fields
+are set in environment variables:
+
+  STEAMGUARD_EVENT, STEAMGUARD_EVENT_ID, STEAMGUARD_EVENT_TIME,
+  STEAMGUARD_ACCOUNT, STEAMGUARD_MAFILES, STEAMGUARD_SOURCE,
+  STEAMGUARD_ERROR, STEAMGUARD_CONFIRMATION_ID,
+  STEAMGUARD_CONFIRMATION_TYPE, STEAMGUARD_CREATOR_ID
+
+Events: ` + strings.Join(notify.EventTypes, ", ") + `
+
+code.generated is only sent to hooks that list it with --event; the code
+itself is not part of the event. Hooks run in the maFiles directory, and a
+command containing a path separator is resolved relative to it. A hook is
+killed when it runs longer than its timeout. Every run is logged with its
+exit status to hooks.log; a non-zero exit status is also reported as a
+warning. Hooks are stored in notifications.json next to the manifest.
+
+Examples:
+  steamguard hook add ./hooks/on-confirmation.sh --event confirmation.new
+  steamguard hook add /usr/local/bin/notify-chat --account bot1 --timeout 10s -- --channel ops
+  steamguard hook add ./hooks/audit.sh --event code.generated,account.added
+  steamguard hook
+  steamguard hook test ./hooks/on-confirmation.sh
+  steamguard hook remove ./hooks/audit.sh`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadNotifyConfig()
+		if len(cfg.Hooks) == 0 {
+			fmt.Println("No hooks. Use 'steamguard hook add <command>' to add one.")
+			return
+		}
+
+		for _, hook := range cfg.Hooks {
+			fmt.Println(strings.Join(append([]string{hook.Command}, hook.Args...), " "))
+			fmt.Printf("    Accounts: %s\n", listOrAll(hook.Accounts))
+			fmt.Printf("    Events: %s\n", listOrAll(hook.Events))
+			fmt.Printf("    Timeout: %s\n", hookTimeoutOf(hook))
+		}
+	},
+}
+
+var hookAddCmd = &cobra.Command{
+	Use:   "add <command> [-- args...]",
+	Short: "Add a hook",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := buildNotifyFilter(hookAccounts, hookEvents)
+
+		cfg := loadNotifyConfig()
+		for _, hook := range cfg.Hooks {
+			if hook.Command == args[0] {
+				fmt.Fprintf(os.Stderr, "Error: hook %s already exists\n", args[0])
+				os.Exit(1)
+			}
+		}
+		if hookTimeout < time.Second {
+			fmt.Fprintln(os.Stderr, "Error: --timeout must be at least 1s")
+			os.Exit(1)
+		}
+
+		cfg.Hooks = append(cfg.Hooks, notify.HookConfig{
+			Command:        args[0],
+			Args:           args[1:],
+			Filter:         filter,
+			TimeoutSeconds: int(hookTimeout / time.Second),
+		})
+		saveNotifyConfig(cfg)
+		fmt.Printf("✓ Added hook %s\n", args[0])
+	},
+}
+
+var hookRemoveCmd = &cobra.Command{
+	Use:   "remove <command>",
+	Short: "Remove a hook",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadNotifyConfig()
+		kept := cfg.Hooks[:0]
+		for _, hook := range cfg.Hooks {
+			if hook.Command != args[0] {
+				kept = append(kept, hook)
+			}
+		}
+		if len(kept) == len(cfg.Hooks) {
+			fmt.Fprintf(os.Stderr, "Error: hook %s not found\n", args[0])
+			os.Exit(1)
+		}
+		cfg.Hooks = kept
+		saveNotifyConfig(cfg)
+		fmt.Printf("✓ Removed hook %s\n", args[0])
+	},
+}
+
+var hookTestCmd = &cobra.Command{
+	Use:   "test [command]",
+	Short: "Run one or all hooks with a test event",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadNotifyConfig()
+
+		event := notify.Event{
+			ID:   fmt.Sprintf("test-%d", time.Now().UnixNano()),
+			Type: "test",
+			Time: time.Now().UTC(),
+		}
+
+		ran, failed := 0, 0
+		for _, hook := range cfg.Hooks {
+			if len(args) == 1 && hook.Command != args[0] {
+				continue
+			}
+			ran++
+			if err := notify.NewHook(hook, config.GetMaFilesPath()).Send(event); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", hook.Command, err)
+				failed++
+				continue
+			}
+			fmt.Printf("✓ %s\n", hook.Command)
+		}
+
+		if ran == 0 {
+			fmt.Fprintln(os.Stderr, "Error: no matching hook")
+			os.Exit(1)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// hookTimeoutOf returns the effective timeout of a hook
+func hookTimeoutOf(hook notify.HookConfig) time.Duration {
+	if hook.TimeoutSeconds <= 0 {
+		return notify.DefaultHookTimeout
+	}
+	return time.Duration(hook.TimeoutSeconds) * time.Second
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookAddCmd)
+	hookCmd.AddCommand(hookRemoveCmd)
+	hookCmd.AddCommand(hookTestCmd)
+
+	hookAddCmd.Flags().StringSliceVar(&hookAccounts, "account", nil, "Only events of these accounts (default: all)")
+	hookAddCmd.Flags().StringSliceVar(&hookEvents, "event", nil, "Only these events (default: all but code.generated)")
+	hookAddCmd.Flags().DurationVar(&hookTimeout, "timeout", notify.DefaultHookTimeout, "Kill the hook after this long")
+}
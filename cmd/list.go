@@ -2,28 +2,68 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/devhooly/steamguard-go/internal/manifest"
 	"github.com/spf13/cobra"
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all accounts",
-	Long:  `Shows a list of all configured Steam Guard accounts.`,
+	Long:  `Shows a list of all configured Steam Guard accounts, or those matching --tag/--group.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if manifestMgr.IsEmpty() {
 			fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
 			return
 		}
 
-		accounts := manifestMgr.GetAllAccounts()
+		selector := manifest.Selector{Tags: tagSelectors, Groups: groupSelectors}
+		accounts := manifestMgr.SelectAccounts(selector)
 		fmt.Printf("Found accounts: %d\n\n", len(accounts))
-		
-		for i, acc := range accounts {
-			fmt.Printf("[%d] %s\n", i+1, acc.AccountName)
+
+		defaultAccount, _ := manifestMgr.GetAccount("")
+		aliases := manifestMgr.GetAliases()
+
+		// Indexes are positions in the whole manifest so they can be used with -u
+		for i, acc := range manifestMgr.GetAllAccounts() {
+			meta := manifestMgr.GetMetadata(acc.AccountName)
+			if !selector.Matches(&meta) {
+				continue
+			}
+
+			marker := ""
+			if acc == defaultAccount {
+				marker = " (default)"
+			}
+			fmt.Printf("[%d] %s%s\n", i+1, acc.AccountName, marker)
+			if !acc.FullyEnrolled {
+				fmt.Println("    ⚠️  Setup not finished, run 'steamguard setup --resume'")
+			}
 			if acc.DeviceID != "" {
 				fmt.Printf("    Device ID: %s\n", acc.DeviceID)
 			}
+
+			var accountAliases []string
+			for alias, name := range aliases {
+				if name == acc.AccountName {
+					accountAliases = append(accountAliases, alias)
+				}
+			}
+			if len(accountAliases) > 0 {
+				sort.Strings(accountAliases)
+				fmt.Printf("    Aliases: %s\n", strings.Join(accountAliases, ", "))
+			}
+			if len(meta.Tags) > 0 {
+				fmt.Printf("    Tags: %s\n", strings.Join(meta.Tags, ", "))
+			}
+			if len(meta.Groups) > 0 {
+				fmt.Printf("    Groups: %s\n", strings.Join(meta.Groups, ", "))
+			}
+			if meta.Notes != "" {
+				fmt.Printf("    Notes: %s\n", meta.Notes)
+			}
 		}
 	},
 }
@@ -31,4 +71,3 @@ var listCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(listCmd)
 }
-
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/market"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	marketAccept      bool
+	marketRejectBelow bool
+	marketJSON        bool
+	marketDryRun      bool
+	marketYes         bool
+)
+
+var marketCmd = &cobra.Command{
+	Use:   "market",
+	Short: "Manage market listing confirmations",
+	Long: `Lists pending market listing confirmations grouped by account, with the
+item, app ID, what the buyer pays and what you receive, and the total value.
+
+--accept accepts the listings priced at or above their price floor, after
+the listings are shown and the action confirmed; use --yes for
+non-interactive use.
+Listings below the floor, or whose price can't be determined, are never
+accepted; --reject-below rejects them. Floors are set per item name, which
+is matched ignoring case, with 'market floor set'; the item "*" sets the
+floor of all other items.
+Prices are compared to what the buyer pays, in the wallet currency.
+
+Examples:
+  steamguard market
+  steamguard --group fleet-a market --json
+  steamguard market floor set "Mann Co. Supply Crate Key" 2.20
+  steamguard market floor set "*" 0.10
+  steamguard market --accept --reject-below --yes`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if manifestMgr.IsEmpty() {
+			fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
+			return
+		}
+
+		accounts, err := selectAccounts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		client := newClient()
+		client.DryRun = marketDryRun
+		floors := manifestMgr.GetPriceFloors()
+
+		failed := 0
+		var all []*market.Listing
+		grouped := make(map[string][]*market.Listing)
+		for _, account := range accounts {
+			listings, err := loadListings(client, account, floors)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get confirmations of %s: %v\n", account.AccountName, err)
+				failed++
+				continue
+			}
+			grouped[account.AccountName] = listings
+			all = append(all, listings...)
+		}
+
+		if marketJSON {
+			output := struct {
+				Accounts map[string][]*market.Listing `json:"accounts"`
+				Total    market.Totals                `json:"total"`
+			}{grouped, market.Sum(all)}
+
+			data, err := json.MarshalIndent(output, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			for _, account := range accounts {
+				if listings, ok := grouped[account.AccountName]; ok {
+					printListings(account.AccountName, listings)
+				}
+			}
+			if len(grouped) > 1 {
+				fmt.Printf("Total of %d accounts: %s\n", len(grouped), formatTotals(market.Sum(all)))
+			}
+		}
+
+		if (marketAccept || marketRejectBelow) && !confirmListings(all) {
+			fmt.Println("Cancelled.")
+		} else if marketAccept || marketRejectBelow {
+			for _, account := range accounts {
+				failed += respondToListings(client, account, grouped[account.AccountName])
+			}
+		}
+
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// loadListings fetches the market listing confirmations of an account with their details
+func loadListings(client *steamapi.Client, account *manifest.SteamGuardAccount, floors map[string]float64) ([]*market.Listing, error) {
+	confirmations, err := client.GetConfirmations(account)
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]*market.Listing, 0, len(confirmations))
+	for _, conf := range confirmations {
+		if conf.Type != steamapi.ConfTypeMarketListing {
+			continue
+		}
+		details, err := client.GetConfirmationDetails(account, conf)
+		if err != nil {
+			// Without details the price is unknown, so the listing is never accepted
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to get details of %s: %v\n", conf.ID, err)
+		}
+		listings = append(listings, market.NewListing(account.AccountName, conf, details, floors))
+	}
+	return listings, nil
+}
+
+// printListings prints the listings of one account with their total
+func printListings(accountName string, listings []*market.Listing) {
+	fmt.Printf("=== %s ===\n", accountName)
+	if len(listings) == 0 {
+		fmt.Println("No pending market listings.")
+		fmt.Println()
+		return
+	}
+
+	for i, listing := range listings {
+		name := listing.ItemName
+		if listing.AppID != 0 {
+			name = fmt.Sprintf("%s (app %d)", name, listing.AppID)
+		}
+		fmt.Printf("[%d] %s\n", i+1, name)
+		if listing.PriceKnown {
+			fmt.Printf("    Buyer pays: %s\n", valueOr(listing.BuyerPays, "?"))
+			fmt.Printf("    You receive: %s\n", valueOr(listing.YouReceive, "?"))
+		} else {
+			fmt.Printf("    Price: unknown\n")
+		}
+		if listing.Floor != nil {
+			fmt.Printf("    Floor: %.2f\n", *listing.Floor)
+		}
+		if err := listing.Check(); err != nil {
+			fmt.Printf("    ⚠️  %v\n", err)
+		}
+		fmt.Printf("    ID: %s\n", listing.Confirmation.ID)
+		fmt.Printf("    Listing: %s\n", listing.Confirmation.CreatorID)
+	}
+	fmt.Printf("Total: %s\n\n", formatTotals(market.Sum(listings)))
+}
+
+// formatTotals describes the totals in one line
+func formatTotals(totals market.Totals) string {
+	text := fmt.Sprintf("%d listing(s), buyers pay %.2f, you receive %.2f", totals.Count, totals.Price, totals.Receive)
+	if totals.Unknown > 0 {
+		text += fmt.Sprintf(" (%d without a price)", totals.Unknown)
+	}
+	return text
+}
+
+// valueOr returns the value or a fallback when it's empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// confirmListings asks before --accept and --reject-below act on the
+// listings shown, unless --yes or --dry-run was given
+func confirmListings(listings []*market.Listing) bool {
+	var accept, reject int
+	for _, listing := range listings {
+		if listing.Check() == nil {
+			accept++
+		} else {
+			reject++
+		}
+	}
+
+	if !marketAccept {
+		accept = 0
+	}
+	if !marketRejectBelow {
+		reject = 0
+	}
+
+	var question string
+	switch {
+	case accept == 0 && reject == 0, marketYes, marketDryRun:
+		return true
+	case reject == 0:
+		question = fmt.Sprintf("Accept %d listing(s)?", accept)
+	case accept == 0:
+		question = fmt.Sprintf("Reject %d listing(s)?", reject)
+	default:
+		question = fmt.Sprintf("Accept %d and reject %d listing(s)?", accept, reject)
+	}
+	if !isInteractive() {
+		fmt.Fprintln(os.Stderr, "Error: refusing to act without a terminal, use --yes")
+		os.Exit(1)
+	}
+	return confirm(question)
+}
+
+// respondToListings accepts listings passing their floor and, with
+// --reject-below, rejects the others. Returns the number of failures.
+func respondToListings(client *steamapi.Client, account *manifest.SteamGuardAccount, listings []*market.Listing) int {
+	var accept, reject []*steamapi.Confirmation
+	for _, listing := range listings {
+		if listing.Check() == nil {
+			accept = append(accept, listing.Confirmation)
+		} else {
+			reject = append(reject, listing.Confirmation)
+		}
+	}
+
+	failed := 0
+	if marketAccept && len(accept) > 0 {
+		failed += printConfirmationResults(client, account, client.AcceptConfirmations(account, accept), true)
+	}
+	if marketRejectBelow && len(reject) > 0 {
+		failed += printConfirmationResults(client, account, client.RejectConfirmations(account, reject), false)
+	}
+	return failed
+}
+
+var marketFloorCmd = &cobra.Command{
+	Use:   "floor",
+	Short: "List market price floors",
+	Run: func(cmd *cobra.Command, args []string) {
+		floors := manifestMgr.GetPriceFloors()
+		if len(floors) == 0 {
+			fmt.Println("No price floors. Use 'steamguard market floor set <item> <price>' to add one.")
+			return
+		}
+
+		items := make([]string, 0, len(floors))
+		for item := range floors {
+			items = append(items, item)
+		}
+		sort.Strings(items)
+
+		for _, item := range items {
+			fmt.Printf("%s\t%.2f\n", item, floors[item])
+		}
+	},
+}
+
+var marketFloorSetCmd = &cobra.Command{
+	Use:   "set <item> <price>",
+	Short: "Set the price floor of an item, \"*\" for all other items",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		floor, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid price %q\n", args[1])
+			os.Exit(1)
+		}
+
+		if err := manifestMgr.SetPriceFloor(args[0], floor); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ %s: %.2f\n", args[0], floor)
+	},
+}
+
+var marketFloorRemoveCmd = &cobra.Command{
+	Use:   "remove <item>",
+	Short: "Remove the price floor of an item",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := manifestMgr.RemovePriceFloor(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Removed the price floor of %s\n", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(marketCmd)
+	marketCmd.AddCommand(marketFloorCmd)
+	marketFloorCmd.AddCommand(marketFloorSetCmd)
+	marketFloorCmd.AddCommand(marketFloorRemoveCmd)
+
+	marketCmd.Flags().BoolVar(&marketAccept, "accept", false, "Accept listings priced at or above their floor")
+	marketCmd.Flags().BoolVar(&marketRejectBelow, "reject-below", false, "Reject listings below their floor or without a price")
+	marketCmd.Flags().BoolVar(&marketJSON, "json", false, "Output as JSON")
+	marketCmd.Flags().BoolVar(&marketDryRun, "dry-run", false, "Show what would be accepted or rejected, but don't")
+	marketCmd.Flags().BoolVarP(&marketYes, "yes", "y", false, "Don't ask before --accept or --reject-below")
+}
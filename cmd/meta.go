@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metaAddTags      []string
+	metaRemoveTags   []string
+	metaAddGroups    []string
+	metaRemoveGroups []string
+	metaNotes        string
+	metaSetAPIKey    bool
+	metaRemoveAPIKey bool
+)
+
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Show or edit account tags, groups and notes",
+	Long: `Shows or edits the metadata of an account: tags, groups, notes and
+creation date. Metadata is stored in steamguard.json next to the manifest,
+so the maFiles stay compatible with Steam Desktop Authenticator.
+
+The Web API key of an account, used for trade offers when the session has
+no access token, is kept in its maFile and encrypted with it. --set-api-key
+asks for the key, or reads it from stdin, so it doesn't end up in the shell
+history.
+
+Examples:
+  steamguard -u bot1 meta --add-tag market --add-group fleet-a
+  steamguard -u bot1 meta --notes "Owned by Alice"
+  steamguard -u bot1 meta --set-api-key
+  cat bot1.apikey | steamguard -u bot1 meta --set-api-key
+  steamguard --tag market list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if manifestMgr.IsEmpty() {
+			fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
+			return
+		}
+
+		accounts, err := selectAccounts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		edit := len(metaAddTags) > 0 || len(metaRemoveTags) > 0 ||
+			len(metaAddGroups) > 0 || len(metaRemoveGroups) > 0 || cmd.Flags().Changed("notes")
+
+		if metaSetAPIKey || metaRemoveAPIKey {
+			if metaSetAPIKey && metaRemoveAPIKey {
+				fmt.Fprintln(os.Stderr, "Error: use either --set-api-key or --remove-api-key")
+				os.Exit(1)
+			}
+			if len(accounts) != 1 {
+				fmt.Fprintln(os.Stderr, "Error: a Web API key belongs to one account, select it with -u")
+				os.Exit(1)
+			}
+			setAPIKey(accounts[0])
+		}
+
+		for _, account := range accounts {
+			meta := manifestMgr.GetMetadata(account.AccountName)
+
+			if edit {
+				for _, tag := range metaAddTags {
+					meta.AddTag(tag)
+				}
+				for _, tag := range metaRemoveTags {
+					meta.RemoveTag(tag)
+				}
+				for _, group := range metaAddGroups {
+					meta.AddGroup(group)
+				}
+				for _, group := range metaRemoveGroups {
+					meta.RemoveGroup(group)
+				}
+				if cmd.Flags().Changed("notes") {
+					meta.Notes = metaNotes
+				}
+
+				if err := manifestMgr.SetMetadata(account.AccountName, meta); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			fmt.Printf("%s\n", account.AccountName)
+			fmt.Printf("    Tags: %s\n", strings.Join(meta.Tags, ", "))
+			fmt.Printf("    Groups: %s\n", strings.Join(meta.Groups, ", "))
+			fmt.Printf("    Notes: %s\n", meta.Notes)
+			if account.WebAPIKey != "" {
+				fmt.Println("    API key: set")
+			}
+			if meta.CreatedAt != nil {
+				fmt.Printf("    Created: %s\n", meta.CreatedAt.Format("2006-01-02 15:04"))
+			}
+		}
+	},
+}
+
+// setAPIKey stores or removes the Web API key in the account's maFile
+func setAPIKey(account *manifest.SteamGuardAccount) {
+	key := ""
+	if metaSetAPIKey {
+		var err error
+		key, err = promptSecret(fmt.Sprintf("Web API key of %s: ", account.AccountName))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if key == "" {
+			fmt.Fprintln(os.Stderr, "Error: empty Web API key, use --remove-api-key to remove it")
+			os.Exit(1)
+		}
+	}
+
+	account.WebAPIKey = key
+	if err := manifestMgr.UpdateAccount(account); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(metaCmd)
+	metaCmd.Flags().StringSliceVar(&metaAddTags, "add-tag", nil, "Add tags")
+	metaCmd.Flags().StringSliceVar(&metaRemoveTags, "remove-tag", nil, "Remove tags")
+	metaCmd.Flags().StringSliceVar(&metaAddGroups, "add-group", nil, "Add to groups")
+	metaCmd.Flags().StringSliceVar(&metaRemoveGroups, "remove-group", nil, "Remove from groups")
+	metaCmd.Flags().StringVar(&metaNotes, "notes", "", "Set free-text notes")
+	metaCmd.Flags().BoolVar(&metaSetAPIKey, "set-api-key", false, "Set the Steam Web API key from a prompt or stdin, used when the session has no access token")
+	metaCmd.Flags().BoolVar(&metaRemoveAPIKey, "remove-api-key", false, "Remove the Steam Web API key")
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/devhooly/steamguard-go/internal/config"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/notify"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// notifier returns the dispatcher of the configured notifications, nil when
+// they can't be loaded
+func notifier() *notify.Dispatcher {
+	dispatcher, err := notify.New(config.GetMaFilesPath(), log.New(os.Stderr, "⚠️  ", 0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return nil
+	}
+	return dispatcher
+}
+
+// notifyObserver reports the confirmations commands fetch, and sessions or
+// authenticators Steam refused while fetching them
+type notifyObserver struct {
+	mu         sync.Mutex
+	once       sync.Once
+	dispatcher *notify.Dispatcher
+}
+
+// ConfirmationsFetched implements steamapi.Observer
+func (o *notifyObserver) ConfirmationsFetched(account *manifest.SteamGuardAccount, confs []*steamapi.Confirmation, err error) {
+	var events []notify.Event
+	switch {
+	case errors.Is(err, steamapi.ErrNeedAuth):
+		events = append(events, notify.SessionExpiredEvent(account, err))
+	case errors.Is(err, steamapi.ErrAuthenticatorRevoked):
+		events = append(events, notify.AuthenticatorRevokedEvent(account, err))
+	}
+	// Confirmations already reported are dropped by the dispatcher
+	for _, conf := range confs {
+		events = append(events, notify.ConfirmationEvent(notify.EventConfirmationNew, account.AccountName, conf))
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	// Accounts are fetched in parallel, the delivery state is shared
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.once.Do(func() { o.dispatcher = notifier() })
+	o.dispatcher.Dispatch(events...)
+}
+
+// notifyResults reports accepted, rejected and failed confirmations
+func notifyResults(account *manifest.SteamGuardAccount, results []steamapi.ConfirmationResult, action, source, detail string) {
+	eventType := notify.EventConfirmationAccepted
+	if action == "reject" {
+		eventType = notify.EventConfirmationRejected
+	}
+
+	var events []notify.Event
+	for _, result := range results {
+		if result.Err != nil {
+			event := notify.ConfirmationEvent(notify.EventConfirmationFailed, account.AccountName, result.Confirmation)
+			event.Source, event.Detail, event.Error = source, strings.TrimSuffix(action+": "+detail, ": "), result.Err.Error()
+			events = append(events, event)
+			continue
+		}
+		event := notify.ConfirmationEvent(eventType, account.AccountName, result.Confirmation)
+		event.Source, event.Detail = source, detail
+		events = append(events, event)
+	}
+	notifier().Dispatch(events...)
+}
+
+// buildNotifyFilter validates event types and resolves account names to
+// canonical ones, so aliases and prefixes work. Exits on errors.
+func buildNotifyFilter(accounts, events []string) notify.Filter {
+	filter := notify.Filter{Events: events}
+	for _, event := range events {
+		if !slices.Contains(notify.EventTypes, event) {
+			fmt.Fprintf(os.Stderr, "Error: unknown event %q (%s)\n", event, strings.Join(notify.EventTypes, ", "))
+			os.Exit(1)
+		}
+	}
+	for _, name := range accounts {
+		account, err := manifestMgr.GetAccount(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		filter.Accounts = append(filter.Accounts, account.AccountName)
+	}
+	return filter
+}
+
+// loadNotifyConfig loads notifications.json or exits
+func loadNotifyConfig() *notify.Config {
+	cfg, err := notify.LoadConfig(config.GetMaFilesPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// saveNotifyConfig saves notifications.json or exits
+func saveNotifyConfig(cfg *notify.Config) {
+	if err := notify.SaveConfig(config.GetMaFilesPath(), cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// listOrAll joins a list, or returns "all" for an empty one
+func listOrAll(list []string) string {
+	if len(list) == 0 {
+		return "all"
+	}
+	return strings.Join(list, ", ")
+}
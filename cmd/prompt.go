@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// prompt prints a label and reads one line from stdin
+func prompt(label string) (string, error) {
+	fmt.Print(label)
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptSecret reads a secret without echoing it on a terminal, or one
+// line from piped stdin
+func promptSecret(label string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return prompt("")
+	}
+	fmt.Fprint(os.Stderr, label)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(string(secret)), nil
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(label string) bool {
+	answer, err := prompt(label + " [y/N]: ")
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+// isInteractive checks if stdin is a terminal
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirmTyped asks the user to type a word, e.g. an account name, to go ahead
+func confirmTyped(label, expected string) bool {
+	answer, err := prompt(label)
+	return err == nil && answer == expected
+}
@@ -21,20 +21,22 @@ WARNING: Do not use Google Authenticator or Authy - they generate incorrect code
 			return
 		}
 
-		account, err := manifestMgr.GetAccount(username)
+		accounts, err := selectAccounts()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		qr, err := qrcode.GenerateQR(account)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to generate QR code: %v\n", err)
-			os.Exit(1)
-		}
+		for _, account := range accounts {
+			qr, err := qrcode.GenerateQR(account)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to generate QR code: %v\n", err)
+				os.Exit(1)
+			}
 
-		fmt.Printf("\nQR code for account: %s\n\n", account.AccountName)
-		fmt.Println(qr)
+			fmt.Printf("\nQR code for account: %s\n\n", account.AccountName)
+			fmt.Println(qr)
+		}
 		fmt.Println("\n⚠️  Do not use Google Authenticator or Authy!")
 		fmt.Println("Recommended: KeeWeb, 1Password, Bitwarden")
 	},
@@ -5,15 +5,20 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/devhooly/steamguard-go/internal/approval"
 	"github.com/devhooly/steamguard-go/internal/config"
 	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/notify"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
 	"github.com/spf13/cobra"
 )
 
 var (
-	cfgFile     string
-	username    string
-	manifestMgr *manifest.Manager
+	cfgFile        string
+	username       string
+	tagSelectors   []string
+	groupSelectors []string
+	manifestMgr    *manifest.Manager
 )
 
 var rootCmd = &cobra.Command{
@@ -38,26 +43,74 @@ Features:
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		// By default, generate code for the first account or specified username
+		// By default, generate code for the default account or specified username
 		if manifestMgr.IsEmpty() {
 			fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
 			return
 		}
 
-		account, err := manifestMgr.GetAccount(username)
+		accounts, err := selectAccounts()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		code, err := account.GenerateCode()
+		var events []notify.Event
+		for _, account := range accounts {
+			if err := account.CheckEnrolled(); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+			}
+
+			code, err := account.GenerateCode()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to generate code: %v\n", err)
+				os.Exit(1)
+			}
+
+			if hasSelector() {
+				fmt.Printf("%s\t%s\n", account.AccountName, code)
+			} else {
+				fmt.Println(code)
+			}
+			events = append(events, notify.CodeGeneratedEvent(account))
+		}
+		notifier().Announce(events...)
+	},
+}
+
+// hasSelector checks if --tag or --group was given
+func hasSelector() bool {
+	return len(tagSelectors) > 0 || len(groupSelectors) > 0
+}
+
+// selectAccounts returns the accounts a command works on: the -u account,
+// the accounts matching --tag/--group, or the default account
+func selectAccounts() ([]*manifest.SteamGuardAccount, error) {
+	if username != "" && hasSelector() {
+		return nil, fmt.Errorf("-u can't be combined with --tag or --group")
+	}
+	if !hasSelector() {
+		account, err := manifestMgr.GetAccount(username)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to generate code: %v\n", err)
-			os.Exit(1)
+			return nil, err
 		}
+		return []*manifest.SteamGuardAccount{account}, nil
+	}
 
-		fmt.Println(code)
-	},
+	accounts := manifestMgr.SelectAccounts(manifest.Selector{Tags: tagSelectors, Groups: groupSelectors})
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no accounts match the given --tag/--group")
+	}
+	return accounts, nil
+}
+
+// newClient creates a Steam client that enforces two-person approval and
+// sends notifications about the confirmations it fetches
+func newClient() *steamapi.Client {
+	client := steamapi.NewClient()
+	client.Approver = &approval.Gate{Dir: config.GetMaFilesPath(), Manager: manifestMgr}
+	client.Observer = &notifyObserver{}
+	return client
 }
 
 func Execute() error {
@@ -67,7 +120,9 @@ func Execute() error {
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVarP(&username, "username", "u", "", "Steam username")
+	rootCmd.PersistentFlags().StringVarP(&username, "username", "u", "", "Steam username, alias, SteamID, list index or unique prefix")
+	rootCmd.PersistentFlags().StringSliceVar(&tagSelectors, "tag", nil, "Select accounts having all of these tags")
+	rootCmd.PersistentFlags().StringSliceVar(&groupSelectors, "group", nil, "Select accounts in any of these groups")
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Path to configuration file")
 }
 
@@ -77,4 +132,3 @@ func initConfig() {
 		config.SetMaFilesPath(filepath.Dir(cfgFile))
 	}
 }
-
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/devhooly/steamguard-go/internal/automation"
+	"github.com/devhooly/steamguard-go/internal/market"
+	"github.com/devhooly/steamguard-go/internal/rules"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rulesFile      string
+	rulesAllowHold bool
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Auto-confirm rules",
+	Long: `Auto-confirm rules live in auto_confirm_trades of manifest.json. Rules are
+checked in order and the first matching rule decides; confirmations
+matching no rule are ignored. A rule matches when all its conditions match:
+
+  name            label shown in logs
+  action          accept, reject or ignore
+  type, types     confirmation types: trade, market, apikey, phone, ...
+  creators        trade offer or listing IDs
+  match           regular expression on headline and summary
+  account_tags    the account has all of these tags
+  account_groups  the account is in any of these groups
+  min_price       price from the confirmation details is at least this
+  max_price       price from the confirmation details is at most this
+  partner_allow   the trade partner's SteamID is one of these
+  partner_deny    the trade partner's SteamID is none of these
+  max_items_given the trade takes at most this many items from the account
+  time_of_day     local time range, e.g. "09:00-18:00" or "22:00-06:00"
+  allow_hold      (not a condition) let the rule accept trades that would be held
+
+The price is what the buyer pays for market listings. Partner and item
+conditions use the trade offer (IEconService), price conditions fetch the
+confirmation details; they never match when the value is unknown.
+
+Trades are only accepted when their hold duration with the partner is
+known and zero, unless the rule has "allow_hold": true or the daemon runs
+with --allow-hold.
+
+High-risk confirmations (everything but trade and market, e.g. apikey,
+phone and recovery) are never accepted automatically; a rule accepting
+such a type is an error, and other accepting rules ignore them.
+
+While price floors are set ('market floor'), market listings below their
+floor or with an unknown price are not accepted either.
+
+Example: accept market listings up to $5, reject trades to unknown partners:
+
+  "auto_confirm_trades": [
+    {"name": "cheap listings", "type": "market", "max_price": 5, "action": "accept"},
+    {"name": "friends", "type": "trade", "partner_allow": ["76561198000000001"], "action": "ignore"},
+    {"name": "unknown partners", "type": "trade", "action": "reject"}
+  ]`,
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test <samples.json>",
+	Short: "Evaluate rules against sample confirmations",
+	Long: `Evaluates the rules against sample confirmations without contacting Steam.
+The samples file is a JSON array:
+
+  [
+    {
+      "account": "bot1",
+      "account_tags": ["market"],
+      "time": "2024-05-01T12:00:00Z",
+      "confirmation": {"id": "1", "type": 3, "headline": "Sell - Key"},
+      "details": {"listing": {"buyer_pays": "$2.50"}},
+      "expect": "accept"
+    }
+  ]
+
+"confirmation" has the mobileconf/getlist format and "details" the format
+of 'trade show --json'. Samples with "expect" fail the test when the
+decision differs.
+
+Decisions go through the same guards as the daemon: accepting a high-risk
+confirmation, a trade whose hold isn't known to be zero, or a listing below
+its price floor, becomes ignore and the guard is shown. Give a trade's hold in "confirmation" as
+"offer": {"hold": {"both": 0}}, and --allow-hold like the daemon's.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		defs := manifestMgr.GetManifest().AutoConfirm
+		if rulesFile != "" {
+			data, err := os.ReadFile(rulesFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to read rules: %v\n", err)
+				os.Exit(1)
+			}
+			defs = nil
+			if err := json.Unmarshal(data, &defs); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to parse rules: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		compiled, err := rules.Compile(defs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		samples, err := rules.LoadSamples(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%d rule(s), %d sample(s)\n\n", len(compiled), len(samples))
+
+		failed := 0
+		for i, sample := range samples {
+			conf := sample.Confirmation
+			fmt.Printf("[%d] %s %s %q\n", i+1, conf.Type, conf.ID, conf.Description())
+
+			decision := automation.DecideInput(compiled, sample.Input(), rulesAllowHold, manifestMgr.GetPriceFloors())
+			result := decision.Result
+			guard := guardOf(decision.Err)
+			if decision.Err != nil && guard == "" {
+				failed++
+				fmt.Printf("    ✗ error: %v\n", decision.Err)
+				continue
+			}
+
+			line := fmt.Sprintf("%s by %s", result.Action, result.Source())
+			if guard != "" {
+				line += fmt.Sprintf(", accept overridden by the %s guard: %v", guard, errors.Unwrap(decision.Err))
+			}
+			if sample.Expect == "" {
+				fmt.Printf("    → %s\n", line)
+				continue
+			}
+
+			expected, _ := rules.ParseAction(sample.Expect)
+			if expected != result.Action {
+				failed++
+				fmt.Printf("    ✗ %s, expected %s\n", line, expected)
+				continue
+			}
+			fmt.Printf("    ✓ %s\n", line)
+		}
+
+		if failed > 0 {
+			fmt.Printf("\n%d of %d sample(s) failed\n", failed, len(samples))
+			os.Exit(1)
+		}
+	},
+}
+
+// guardOf names the guard that turned an accept into ignore, "" for other errors
+func guardOf(err error) string {
+	switch {
+	case errors.Is(err, steamapi.ErrHighRisk):
+		return "high-risk"
+	case errors.Is(err, steamapi.ErrTradeHeld):
+		return "trade hold"
+	case errors.Is(err, market.ErrBelowFloor):
+		return "price floor"
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesTestCmd)
+	rulesTestCmd.Flags().BoolVar(&rulesAllowHold, "allow-hold", false, "Accept trades that would be held, like 'daemon --allow-hold'")
+	rulesTestCmd.Flags().StringVar(&rulesFile, "rules", "", "JSON file with a rules array to test instead of manifest.json")
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devhooly/steamguard-go/internal/enroll"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/notify"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resumeSetup bool
+	batchFile   string
+	batchState  string
+	batchReport string
+)
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Set up Steam Guard for an account",
+	Long: `Logs in to Steam, adds a mobile authenticator and saves it as a maFile.
+
+With --batch, enrolls every account listed in a CSV file with the columns
+username, password and optionally email, imap_host, imap_port, imap_user,
+imap_password, phone_number and phone_country. Progress is checkpointed in
+a state file so an interrupted run can be resumed by running it again, and
+a report with every revocation code is written at the end.
+
+With --resume, finishes accounts whose setup was interrupted after the
+authenticator was added but before it was finalized. If finalization fails,
+offers to revoke the authenticator with the saved revocation code.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		enroller := &enroll.Enroller{
+			Client:        steamapi.NewClient(),
+			Manager:       manifestMgr,
+			Prompt:        prompt,
+			Progress:      notifyEnrolled,
+			BatchProgress: printBatchProgress,
+		}
+
+		if resumeSetup {
+			runResumeSetup(enroller)
+			return
+		}
+		if batchFile != "" {
+			runBatchSetup(enroller)
+			return
+		}
+
+		user := username
+		if user == "" {
+			var err error
+			if user, err = prompt("Steam username: "); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		password, err := prompt("Password: ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		account, err := enroller.Enroll(enroll.Params{Username: user, Password: password})
+		if account != nil {
+			fmt.Printf("\n⚠️  Revocation code: %s\n", account.RevocationCode)
+			fmt.Println("Write it down! It is the only way to remove the authenticator without the maFile.")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Setup failed: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Authenticator added to %s\n", account.AccountName)
+	},
+}
+
+// notifyEnrolled reports accounts whose authenticator is finalized
+func notifyEnrolled(stage enroll.Stage, account *manifest.SteamGuardAccount) error {
+	if stage == enroll.StageDone {
+		notifier().Dispatch(notify.AccountAddedEvent(account))
+	}
+	return nil
+}
+
+// printBatchProgress prints the progress of a batch enrollment
+func printBatchProgress(event enroll.BatchEvent, username string, st *enroll.AccountState) {
+	switch {
+	case event == enroll.BatchSkipped:
+		fmt.Printf("↷ %s: already enrolled, skipping\n", username)
+	case event == enroll.BatchStarted:
+		fmt.Printf("→ %s\n", username)
+	case st.Error != "":
+		fmt.Printf("✗ %s: %s\n", username, st.Error)
+		if st.RevocationCode != "" {
+			fmt.Printf("    Revocation code: %s\n", st.RevocationCode)
+		}
+	default:
+		fmt.Printf("✓ %s: enrolled\n", username)
+	}
+}
+
+// runResumeSetup finalizes partially enrolled accounts or revokes them
+func runResumeSetup(enroller *enroll.Enroller) {
+	accounts := manifestMgr.GetPartialAccounts()
+	if username != "" {
+		account, err := manifestMgr.GetAccount(username)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		accounts = nil
+		if !account.FullyEnrolled {
+			accounts = []*manifest.SteamGuardAccount{account}
+		}
+	}
+
+	if len(accounts) == 0 {
+		fmt.Println("No partially enrolled accounts.")
+		return
+	}
+
+	failed := 0
+	for _, account := range accounts {
+		fmt.Printf("\nAccount %s: authenticator was added but setup was not finished\n", account.AccountName)
+		fmt.Printf("Revocation code: %s\n", account.RevocationCode)
+
+		err := refreshSession(enroller, account)
+		if err == nil {
+			err = enroller.Finalize(account, 0, enroll.Params{Username: account.AccountName})
+		}
+		if err == nil {
+			fmt.Printf("✓ Authenticator of %s finalized\n", account.AccountName)
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(os.Stderr, "Failed to finalize %s: %v\n", account.AccountName, err)
+		if !confirm(fmt.Sprintf("Revoke the authenticator of %s with the saved revocation code?", account.AccountName)) {
+			continue
+		}
+
+		if err := enroller.Client.RemoveAuthenticator(account); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to revoke %s: %v\n", account.AccountName, err)
+			continue
+		}
+		if err := manifestMgr.RemoveAccount(account); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		fmt.Printf("✗ Authenticator of %s revoked and maFile removed\n", account.AccountName)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// refreshSession makes sure the account's access token is usable,
+// logging in again if the refresh token has expired too
+func refreshSession(enroller *enroll.Enroller, account *manifest.SteamGuardAccount) error {
+	if !account.Session.AccessTokenExpired() {
+		return nil
+	}
+	if err := enroller.Client.RefreshAccessToken(&account.Session); err == nil {
+		return manifestMgr.UpdateAccount(account)
+	}
+
+	fmt.Printf("Session of %s has expired, login again\n", account.AccountName)
+	password, err := prompt("Password: ")
+	if err != nil {
+		return err
+	}
+	session, err := enroller.Client.Login(account.AccountName, password, func(kind steamapi.GuardCodeType) (string, error) {
+		if kind == steamapi.GuardCodeDevice {
+			return account.GenerateCode()
+		}
+		return prompt("Enter Steam Guard code sent to email: ")
+	})
+	if err != nil {
+		return err
+	}
+
+	account.Session = *session
+	return manifestMgr.UpdateAccount(account)
+}
+
+// runBatchSetup enrolls all accounts from the CSV file
+func runBatchSetup(enroller *enroll.Enroller) {
+	statePath := batchState
+	if statePath == "" {
+		statePath = batchFile + ".state.json"
+	}
+	reportPath := batchReport
+	if reportPath == "" {
+		reportPath = batchFile + ".report.txt"
+	}
+
+	params, err := enroll.ReadCSV(batchFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	state, err := enroll.LoadState(statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Accounts in batch: %d\n\n", len(params))
+	runErr := enroller.RunBatch(params, state, statePath)
+
+	if err := enroll.WriteReport(reportPath, params, state); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, p := range params {
+		if st, ok := state.Accounts[p.Username]; !ok || st.Stage != enroll.StageDone {
+			failed++
+		}
+	}
+
+	fmt.Printf("\nEnrolled: %d, not finished: %d\n", len(params)-failed, failed)
+	fmt.Printf("Report with revocation codes: %s\n", reportPath)
+
+	if runErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", runErr)
+		os.Exit(1)
+	}
+	if failed > 0 {
+		fmt.Printf("Run the same command again to resume (state: %s)\n", statePath)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(setupCmd)
+	setupCmd.Flags().BoolVar(&resumeSetup, "resume", false, "Finish or revoke partially enrolled accounts")
+	setupCmd.Flags().StringVar(&batchFile, "batch", "", "CSV file with accounts to enroll")
+	setupCmd.Flags().StringVar(&batchState, "state", "", "Checkpoint file for --batch (default <csv>.state.json)")
+	setupCmd.Flags().StringVar(&batchReport, "report", "", "Summary report for --batch (default <csv>.report.txt)")
+}
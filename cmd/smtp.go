@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/config"
+	"github.com/devhooly/steamguard-go/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	smtpHost     string
+	smtpPort     int
+	smtpStartTLS bool
+	smtpTLS      bool
+	smtpUsername string
+	smtpPassword string
+	smtpFrom     string
+	smtpTo       []string
+	smtpMode     string
+	smtpDigest   time.Duration
+	smtpAccounts []string
+	smtpEvents   []string
+	smtpSubject  string
+	smtpBodyFile string
+	smtpDueOnly  bool
+)
+
+var smtpCmd = &cobra.Command{
+	Use:   "smtp",
+	Short: "Configure email alerts",
+	Long: `Sends email about pending, accepted, rejected and failed confirmations,
+expired sessions and revoked authenticators, through an SMTP server. The
+settings are stored in notifications.json next to the manifest.
+
+In immediate mode every event is mailed on its own. In digest mode events
+are collected and mailed together, at most once per --digest-interval;
+the daemon sends digests while it runs, 'smtp flush' sends one right away.
+
+Subject and body are Go text/template templates rendered with .Events (the
+events of the mail) and .Digest. Every event has .Type, .Time, .Account,
+.Confirmation (.ID, .CreatorID, .Type, .Headline, .Summary), .Source,
+.Detail and .Error. The body is read from --body-file, relative to the
+maFiles directory.
+
+The password can also be given in STEAMGUARD_SMTP_PASSWORD. Credentials
+are only sent over TLS, except to localhost, so a local stand-in like
+MailHog can be used for testing without TLS.
+
+Examples:
+  steamguard smtp set --host smtp.example.com --port 587 --starttls \
+      --username alerts --password secret --from steamguard@example.com --to ops@example.com
+  steamguard smtp set --mode digest --digest-interval 30m --event confirmation.new,session.expired
+  steamguard smtp set --host localhost --port 1025 --starttls=false   # Local SMTP stand-in
+  steamguard smtp test
+  steamguard smtp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		smtp := loadNotifyConfig().SMTP
+		if smtp == nil {
+			fmt.Println("Email alerts are not configured. Use 'steamguard smtp set' to configure them.")
+			return
+		}
+
+		security := "none"
+		if smtp.TLS {
+			security = "TLS"
+		} else if smtp.StartTLS {
+			security = "STARTTLS"
+		}
+		mode := smtp.Mode
+		if mode == "" {
+			mode = notify.MailImmediate
+		}
+		if mode == notify.MailDigest {
+			interval := time.Duration(smtp.DigestMinutes) * time.Minute
+			if interval <= 0 {
+				interval = notify.DefaultDigestInterval
+			}
+			mode = fmt.Sprintf("%s every %s", mode, interval)
+		}
+
+		fmt.Printf("Server: %s:%d (%s)\n", smtp.Host, smtp.Port, security)
+		if smtp.Username != "" {
+			fmt.Printf("User: %s\n", smtp.Username)
+		}
+		if smtp.Password != "" {
+			fmt.Println("Password: ********")
+		}
+		fmt.Printf("From: %s\n", smtp.From)
+		fmt.Printf("To: %s\n", strings.Join(smtp.To, ", "))
+		fmt.Printf("Mode: %s\n", mode)
+		fmt.Printf("Accounts: %s\n", listOrAll(smtp.Accounts))
+		fmt.Printf("Events: %s\n", listOrAll(smtp.Events))
+		if smtp.SubjectTemplate != "" {
+			fmt.Printf("Subject template: %s\n", smtp.SubjectTemplate)
+		}
+		if smtp.BodyFile != "" {
+			fmt.Printf("Body template: %s\n", smtp.BodyFile)
+		}
+	},
+}
+
+var smtpSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Create or change the SMTP settings",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadNotifyConfig()
+		smtp := cfg.SMTP
+		if smtp == nil {
+			smtp = &notify.SMTPConfig{Port: 587, StartTLS: true}
+		}
+
+		flags := cmd.Flags()
+		if flags.Changed("host") {
+			smtp.Host = smtpHost
+		}
+		if flags.Changed("port") {
+			smtp.Port = smtpPort
+		}
+		if flags.Changed("starttls") {
+			smtp.StartTLS = smtpStartTLS
+		}
+		if flags.Changed("tls") {
+			smtp.TLS = smtpTLS
+			// Implicit TLS replaces STARTTLS
+			if smtpTLS && !flags.Changed("starttls") {
+				smtp.StartTLS = false
+			}
+		}
+		if flags.Changed("username") {
+			smtp.Username = smtpUsername
+		}
+		if flags.Changed("password") {
+			smtp.Password = smtpPassword
+		}
+		if flags.Changed("from") {
+			smtp.From = smtpFrom
+		}
+		if flags.Changed("to") {
+			smtp.To = smtpTo
+		}
+		if flags.Changed("mode") {
+			smtp.Mode = smtpMode
+		}
+		if flags.Changed("digest-interval") {
+			smtp.DigestMinutes = int(smtpDigest / time.Minute)
+		}
+		if flags.Changed("account") || flags.Changed("event") {
+			filter := buildNotifyFilter(smtpAccounts, smtpEvents)
+			if flags.Changed("account") {
+				smtp.Accounts = filter.Accounts
+			}
+			if flags.Changed("event") {
+				smtp.Events = filter.Events
+			}
+		}
+		if flags.Changed("subject") {
+			smtp.SubjectTemplate = smtpSubject
+		}
+		if flags.Changed("body-file") {
+			smtp.BodyFile = smtpBodyFile
+		}
+
+		if err := smtp.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		cfg.SMTP = smtp
+		saveNotifyConfig(cfg)
+		fmt.Println("✓ SMTP settings saved")
+	},
+}
+
+var smtpRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the SMTP settings",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadNotifyConfig()
+		if cfg.SMTP == nil {
+			fmt.Fprintln(os.Stderr, "Error: email alerts are not configured")
+			os.Exit(1)
+		}
+		cfg.SMTP = nil
+		saveNotifyConfig(cfg)
+		fmt.Println("✓ SMTP settings removed")
+	},
+}
+
+var smtpTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send a test mail with the configured templates",
+	Run: func(cmd *cobra.Command, args []string) {
+		mailer := configuredMailer()
+
+		event := notify.Event{
+			ID:      fmt.Sprintf("test-%d", time.Now().UnixNano()),
+			Type:    "test",
+			Time:    time.Now().UTC(),
+			Account: "example",
+			Confirmation: &notify.Confirmation{
+				ID:       "1234567890",
+				Type:     "trade",
+				Headline: "Test confirmation",
+				Summary:  []string{"This is a test mail from steamguard"},
+			},
+		}
+		if err := mailer.SendMail(notify.MailData{Events: []notify.Event{event}, Digest: mailer.Config.Mode == notify.MailDigest}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Test mail sent to %s\n", strings.Join(mailer.Config.To, ", "))
+	},
+}
+
+var smtpFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Send the queued digest",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := configuredMailer().Flush(!smtpDueOnly); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ Digest flushed")
+	},
+}
+
+// configuredMailer returns the mailer of the SMTP settings or exits
+func configuredMailer() *notify.Mailer {
+	smtp := loadNotifyConfig().SMTP
+	if smtp == nil {
+		fmt.Fprintln(os.Stderr, "Error: email alerts are not configured, use 'steamguard smtp set'")
+		os.Exit(1)
+	}
+	if err := smtp.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return notify.NewMailer(*smtp, config.GetMaFilesPath())
+}
+
+func init() {
+	rootCmd.AddCommand(smtpCmd)
+	smtpCmd.AddCommand(smtpSetCmd)
+	smtpCmd.AddCommand(smtpRemoveCmd)
+	smtpCmd.AddCommand(smtpTestCmd)
+	smtpCmd.AddCommand(smtpFlushCmd)
+
+	flags := smtpSetCmd.Flags()
+	flags.StringVar(&smtpHost, "host", "", "SMTP server host")
+	flags.IntVar(&smtpPort, "port", 587, "SMTP server port")
+	flags.BoolVar(&smtpStartTLS, "starttls", true, "Upgrade the connection with STARTTLS")
+	flags.BoolVar(&smtpTLS, "tls", false, "Connect with implicit TLS (usually port 465)")
+	flags.StringVar(&smtpUsername, "username", "", "SMTP user")
+	flags.StringVar(&smtpPassword, "password", "", "SMTP password (or STEAMGUARD_SMTP_PASSWORD)")
+	flags.StringVar(&smtpFrom, "from", "", "Sender address")
+	flags.StringSliceVar(&smtpTo, "to", nil, "Recipient addresses")
+	flags.StringVar(&smtpMode, "mode", notify.MailImmediate, "immediate or digest")
+	flags.DurationVar(&smtpDigest, "digest-interval", notify.DefaultDigestInterval, "Least time between digests")
+	flags.StringSliceVar(&smtpAccounts, "account", nil, "Only events of these accounts (default: all)")
+	flags.StringSliceVar(&smtpEvents, "event", nil, "Only these events (default: all)")
+	flags.StringVar(&smtpSubject, "subject", "", "Subject template")
+	flags.StringVar(&smtpBodyFile, "body-file", "", "Body template file, relative to the maFiles directory")
+
+	smtpFlushCmd.Flags().BoolVar(&smtpDueOnly, "due-only", false, "Only send when the digest interval has passed")
+}
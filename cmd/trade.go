@@ -1,86 +1,861 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/devhooly/steamguard-go/internal/history"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/market"
 	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/devhooly/steamguard-go/internal/tui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	acceptAll bool
-	rejectAll bool
+	acceptAll      bool
+	rejectAll      bool
+	interactive    bool
+	showJSON       bool
+	listJSON       bool
+	dryRun         bool
+	tradeAllowHold bool
+
+	tradeAllAccounts bool
+	tradeParallel    int
+	tradeRate        float64
+
+	filterTypes     []string
+	filterCreators  []string
+	filterMatch     string
+	filterOlderThan time.Duration
+	assumeYes       bool
+
+	acceptEverything bool
 )
 
 var tradeCmd = &cobra.Command{
 	Use:   "trade",
 	Short: "Manage trade confirmations",
-	Long:  `View and manage pending Steam trade confirmations.`,
+	Long: `View and manage pending Steam trade confirmations.
+
+Use 'trade accept' and 'trade reject' to act on selected confirmations only,
+or 'trade --interactive' to pick them from a list. With --dry-run everything
+is fetched and previewed but no confirmation is accepted or rejected.
+
+'trade --accept' and 'trade --reject' act on every pending confirmation
+like 'trade accept --all-confirmations': they show a preview and ask
+before acting; use --yes for non-interactive use.
+
+With --all every account is handled. Confirmations of several accounts
+are fetched on --parallel workers, with all requests to Steam limited to
+--rate per second, and a summary with the failures of every account is
+printed at the end.
+
+'trade --json' writes the pending confirmations, grouped by account, in the
+format read by 'daemon --replay'.
+
+Examples:
+  steamguard trade --all
+  steamguard trade --all --accept --yes --parallel 16 --rate 20
+  steamguard trade accept --all --type market --yes`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if manifestMgr.IsEmpty() {
 			fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
 			return
 		}
 
-		account, err := manifestMgr.GetAccount(username)
+		// --accept and --reject act on every confirmation, with the preview
+		// and confirmation of 'trade accept --all-confirmations'
+		if (acceptAll || rejectAll) && !listJSON {
+			if acceptAll && rejectAll {
+				fmt.Fprintln(os.Stderr, "Error: --accept can't be combined with --reject")
+				os.Exit(1)
+			}
+			acceptEverything = acceptAll
+			runTradeAction(nil, acceptAll)
+			return
+		}
+
+		accounts, err := tradeAccounts()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		client := steamapi.NewClient()
-		
-		// Get list of confirmations
-		confirmations, err := client.GetConfirmations(account)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to get confirmations: %v\n", err)
+		client := tradeClient()
+
+		if listJSON {
+			printConfirmationsJSON(client, accounts)
+			return
+		}
+
+		fetched := client.FetchConfirmations(accounts, steamapi.FetchOptions{
+			Parallel: tradeParallel,
+			Offers:   !interactive,
+		})
+
+		report := &tradeReport{accounts: len(accounts)}
+		for _, result := range fetched {
+			if len(accounts) > 1 {
+				fmt.Printf("=== %s ===\n", result.Account.AccountName)
+			}
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get confirmations: %v\n", result.Err)
+				report.fail(result.Account, "failed to get confirmations: %v", result.Err)
+			} else {
+				if result.OfferErr != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  %v\n", result.OfferErr)
+				}
+				if err := processConfirmations(client, result.Account, result.Confirmations, report); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					report.fail(result.Account, "%v", err)
+				}
+			}
+			if len(accounts) > 1 {
+				fmt.Println()
+			}
+		}
+
+		if len(accounts) > 1 {
+			report.print("")
+		}
+		if len(report.failures) > 0 {
 			os.Exit(1)
 		}
+	},
+}
+
+// tradeAccounts returns every account with --all, otherwise the selected ones
+func tradeAccounts() ([]*manifest.SteamGuardAccount, error) {
+	if !tradeAllAccounts {
+		return selectAccounts()
+	}
+	if username != "" || hasSelector() {
+		return nil, fmt.Errorf("--all can't be combined with -u, --tag or --group")
+	}
+	return manifestMgr.GetAllAccounts(), nil
+}
+
+// tradeClient creates a client honoring --dry-run and --rate
+func tradeClient() *steamapi.Client {
+	client := newClient()
+	client.DryRun = dryRun
+	client.SetRateLimit(tradeRate)
+	return client
+}
+
+// tradeReport sums up a run over several accounts
+type tradeReport struct {
+	accounts      int
+	confirmations int
+	done          int
+	failures      []string
+}
+
+// fail records a failure of an account
+func (r *tradeReport) fail(account *manifest.SteamGuardAccount, format string, args ...interface{}) {
+	r.failures = append(r.failures, account.AccountName+": "+fmt.Sprintf(format, args...))
+}
+
+// results counts the outcome of accepting or rejecting on one account
+func (r *tradeReport) results(account *manifest.SteamGuardAccount, results []steamapi.ConfirmationResult, failed int, verb string) {
+	r.done += len(results) - failed
+	if failed > 0 {
+		r.fail(account, "failed to %s %d of %d confirmation(s)", verb, failed, len(results))
+	}
+}
+
+// print prints the summary; done names what happened to the confirmations,
+// empty when they were only listed
+func (r *tradeReport) print(done string) {
+	if dryRun && done != "" {
+		done = "Would " + strings.ToLower(strings.TrimSuffix(done, "ed")) + " (dry run)"
+	}
+
+	fmt.Println("=== Summary ===")
+	fmt.Printf("Accounts: %d, failed: %d\n", r.accounts, len(r.failures))
+	fmt.Printf("Confirmations: %d\n", r.confirmations)
+	if done != "" {
+		fmt.Printf("%s: %d\n", done, r.done)
+	}
+	if len(r.failures) > 0 {
+		fmt.Println("Failures:")
+		for _, failure := range r.failures {
+			fmt.Printf("    %s\n", failure)
+		}
+	}
+}
+
+// printConfirmationsJSON prints pending confirmations grouped by account name
+func printConfirmationsJSON(client *steamapi.Client, accounts []*manifest.SteamGuardAccount) {
+	output := make(map[string][]*steamapi.Confirmation)
+	failed := false
+	for _, result := range client.FetchConfirmations(accounts, steamapi.FetchOptions{Parallel: tradeParallel, Offers: true}) {
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get confirmations of %s: %v\n", result.Account.AccountName, result.Err)
+			failed = true
+			continue
+		}
+		if result.OfferErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", result.OfferErr)
+		}
+		output[result.Account.AccountName] = result.Confirmations
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// processConfirmations lists the confirmations of one account or lets the
+// user pick from them
+func processConfirmations(client *steamapi.Client, account *manifest.SteamGuardAccount, confirmations []*steamapi.Confirmation, report *tradeReport) error {
+	report.confirmations += len(confirmations)
+	if len(confirmations) == 0 {
+		fmt.Println("No pending confirmations.")
+		return nil
+	}
+
+	fmt.Printf("Found confirmations: %d\n\n", len(confirmations))
+
+	if interactive {
+		return pickConfirmations(client, account, confirmations)
+	}
+
+	// Show list, trade offers are already attached
+	for i, conf := range confirmations {
+		printConfirmation(i+1, conf)
+	}
+	fmt.Println("Use 'trade accept' or 'trade reject' to manage confirmations.")
+	return nil
+}
+
+// pickConfirmations lets the user select confirmations and accept or reject them in one batch
+func pickConfirmations(client *steamapi.Client, account *manifest.SteamGuardAccount, confirmations []*steamapi.Confirmation) error {
+	items := make([]tui.Item, len(confirmations))
+	for i, conf := range confirmations {
+		items[i] = tui.Item{
+			Title: conf.Headline,
+			Lines: []string{fmt.Sprintf("%s · %s · %s", conf.TypeName, strings.Join(conf.Summary, " "), conf.Created().Format("2006-01-02 15:04"))},
+		}
+	}
+
+	picker := &tui.Picker{
+		Items:  items,
+		Prompt: prompt,
+		Details: func(index int) (string, error) {
+			conf := confirmations[index]
+			details, err := client.GetConfirmationDetails(account, conf)
+			if err != nil {
+				return "", err
+			}
+			return formatConfirmationDetails(conf, details), nil
+		},
+	}
+
+	action, indexes, err := picker.Run()
+	if err != nil {
+		return err
+	}
+	if action == tui.ActionNone {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	picked := make([]*steamapi.Confirmation, len(indexes))
+	for i, index := range indexes {
+		picked[i] = confirmations[index]
+	}
+
+	// Picking everything at once is a bulk accept, ask again
+	if action == tui.ActionAccept && len(picked) > 1 && len(picked) == len(confirmations) &&
+		!confirm(fmt.Sprintf("Accept all %d pending confirmations?", len(picked))) {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	if action == tui.ActionAccept && len(picked) == 1 && steamapi.CheckRisk(picked[0]) != nil {
+		if !confirmHighRisk(account, picked[0]) {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+		acceptHighRisk(client, account, picked[0])
+	} else if action == tui.ActionAccept {
+		picked = skipHighRisk(account, skipBelowFloor(client, account, picked))
+		printConfirmationResults(client, account, client.AcceptConfirmations(account, picked), true)
+	} else {
+		printConfirmationResults(client, account, client.RejectConfirmations(account, picked), false)
+	}
+
+	return nil
+}
+
+// formatConfirmationDetails renders confirmation details as readable text
+func formatConfirmationDetails(conf *steamapi.Confirmation, details *steamapi.ConfirmationDetails) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ID: %s\n", conf.ID)
+	fmt.Fprintf(&b, "Type: %s (%s risk)\n", conf.TypeName, conf.Type.Risk())
+	fmt.Fprintf(&b, "Creator: %s\n", conf.CreatorID)
+	fmt.Fprintf(&b, "Created: %s\n", conf.Created().Format("2006-01-02 15:04:05"))
+
+	if details.Partner != nil {
+		partner := details.Partner.Persona
+		if details.Partner.SteamID != "" {
+			partner = strings.TrimSpace(fmt.Sprintf("%s (%s)", partner, details.Partner.SteamID))
+		}
+		fmt.Fprintf(&b, "Partner: %s\n", partner)
+	} else if conf.Offer != nil {
+		fmt.Fprintf(&b, "Partner: %s\n", conf.Offer.PartnerSteamID)
+	}
 
-		if len(confirmations) == 0 {
-			fmt.Println("No pending confirmations.")
+	given, received := details.Given, details.Received
+	if offer := conf.Offer; offer != nil {
+		// The offer lists the exact assets, prefer it over the parsed page
+		given, received = offer.ItemsToGive, offer.ItemsToReceive
+		fmt.Fprintf(&b, "Offer: %s (%s)\n", offer.ID, offer.State)
+		if offer.Message != "" {
+			fmt.Fprintf(&b, "Message: %s\n", offer.Message)
+		}
+		if offer.Hold != nil {
+			fmt.Fprintf(&b, "Trade hold: %s\n", offer.Hold)
+		}
+		if offer.EscrowEnd != nil {
+			fmt.Fprintf(&b, "Escrow until: %s\n", offer.EscrowEnd.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	if given != nil || received != nil {
+		fmt.Fprintf(&b, "\nYou give (%d):\n", len(given))
+		writeTradeItems(&b, given)
+		fmt.Fprintf(&b, "\nYou receive (%d):\n", len(received))
+		writeTradeItems(&b, received)
+	}
+
+	if details.Listing != nil {
+		b.WriteString("\n")
+		if details.Listing.ItemName != "" {
+			fmt.Fprintf(&b, "Item: %s\n", details.Listing.ItemName)
+		}
+		if details.Listing.AppID != 0 {
+			fmt.Fprintf(&b, "App: %d\n", details.Listing.AppID)
+		}
+		if details.Listing.BuyerPays != "" {
+			fmt.Fprintf(&b, "Buyer pays: %s\n", details.Listing.BuyerPays)
+		}
+		if details.Listing.YouReceive != "" {
+			fmt.Fprintf(&b, "You receive: %s\n", details.Listing.YouReceive)
+		}
+	}
+
+	// Nothing recognized, show the page text as is
+	if details.Partner == nil && given == nil && received == nil && details.Listing == nil {
+		b.WriteString("\n")
+		for _, line := range details.Text {
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writeTradeItems writes one side of a trade
+func writeTradeItems(b *strings.Builder, items []steamapi.TradeItem) {
+	if len(items) == 0 {
+		b.WriteString("    nothing\n")
+		return
+	}
+	for _, item := range items {
+		name := item.Name
+		if name == "" {
+			name = fmt.Sprintf("class %s", item.ClassID)
+		}
+		if item.Amount > 1 {
+			name = fmt.Sprintf("%d x %s", item.Amount, name)
+		}
+		fmt.Fprintf(b, "    %s (app %d)\n", name, item.AppID)
+	}
+}
+
+var tradeShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show the details of a confirmation",
+	Long: `Shows what a confirmation gives and receives: the trade partner,
+items of both sides and the price of market listings. The ID can be a
+confirmation ID or a creator ID (trade offer or listing ID).
+
+Examples:
+  steamguard trade show 1234567890
+  steamguard trade show 1234567890 --json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if manifestMgr.IsEmpty() {
+			fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
 			return
 		}
 
-		fmt.Printf("Found confirmations: %d\n\n", len(confirmations))
+		accounts, err := tradeAccounts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 
-		if acceptAll {
-			// Accept all
-			for _, conf := range confirmations {
-				err := client.AcceptConfirmation(account, conf)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Failed to accept confirmation %s: %v\n", conf.ID, err)
-					continue
-				}
-				fmt.Printf("✓ Accepted: %s\n", conf.Description)
+		client := tradeClient()
+		filter := steamapi.ConfirmationFilter{IDs: args}
+
+		for _, account := range accounts {
+			confirmations, err := client.GetConfirmations(account)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get confirmations of %s: %v\n", account.AccountName, err)
+				continue
+			}
+
+			matched := filter.Apply(confirmations)
+			if len(matched) == 0 {
+				continue
 			}
-		} else if rejectAll {
-			// Reject all
-			for _, conf := range confirmations {
-				err := client.RejectConfirmation(account, conf)
+
+			conf := matched[0]
+			attachTradeOffers(client, account, matched)
+			details, err := client.GetConfirmationDetails(account, conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get confirmation details: %v\n", err)
+				os.Exit(1)
+			}
+
+			if showJSON {
+				output := struct {
+					Account      string                        `json:"account"`
+					Confirmation *steamapi.Confirmation        `json:"confirmation"`
+					Details      *steamapi.ConfirmationDetails `json:"details"`
+				}{account.AccountName, conf, details}
+
+				data, err := json.MarshalIndent(output, "", "  ")
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Failed to reject confirmation %s: %v\n", conf.ID, err)
-					continue
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
 				}
-				fmt.Printf("✗ Rejected: %s\n", conf.Description)
+				fmt.Println(string(data))
+				return
 			}
-		} else {
-			// Show list
-			for i, conf := range confirmations {
-				fmt.Printf("[%d] %s\n", i+1, conf.Description)
-				fmt.Printf("    ID: %s\n", conf.ID)
-				fmt.Printf("    Type: %s\n", conf.Type)
+
+			fmt.Printf("=== %s ===\n", account.AccountName)
+			fmt.Println(conf.Headline)
+			fmt.Println(formatConfirmationDetails(conf, details))
+			return
+		}
+
+		fmt.Fprintf(os.Stderr, "Error: confirmation %s not found\n", args[0])
+		os.Exit(1)
+	},
+}
+
+// printConfirmationResults prints the outcome of accepting or rejecting, returns the number of failures
+func printConfirmationResults(client *steamapi.Client, account *manifest.SteamGuardAccount, results []steamapi.ConfirmationResult, accept bool) int {
+	verb, done, mark := "reject", "Rejected", "✗"
+	if accept {
+		verb, done, mark = "accept", "Accepted", "✓"
+	}
+	if client.DryRun {
+		done = "Would " + verb + " (dry run)"
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "Failed to %s confirmation %s of %s: %v\n", verb, result.Confirmation.ID, account.AccountName, result.Err)
+			continue
+		}
+		fmt.Printf("%s %s: %s\n", mark, done, result.Confirmation.Description())
+	}
+
+	if !client.DryRun {
+		recordHistory(account, results, verb, history.SourceManual, "")
+		notifyResults(account, results, verb, history.SourceManual, "")
+	}
+	return failed
+}
+
+// printConfirmation prints one confirmation of a list
+func printConfirmation(index int, conf *steamapi.Confirmation) {
+	fmt.Printf("[%d] %s\n", index, conf.Headline)
+	for _, line := range conf.Summary {
+		if line != "" {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	fmt.Printf("    ID: %s\n", conf.ID)
+	fmt.Printf("    Type: %s (%s risk)\n", conf.TypeName, conf.Type.Risk())
+	fmt.Printf("    Creator: %s\n", conf.CreatorID)
+	fmt.Printf("    Created: %s\n", conf.Created().Format("2006-01-02 15:04:05"))
+	if offer := conf.Offer; offer != nil {
+		fmt.Printf("    Partner: %s\n", offer.PartnerSteamID)
+		fmt.Printf("    Gives: %s\n", summarizeItems(offer.ItemsToGive))
+		fmt.Printf("    Receives: %s\n", summarizeItems(offer.ItemsToReceive))
+		if offer.Message != "" {
+			fmt.Printf("    Message: %s\n", offer.Message)
+		}
+		if offer.Hold != nil {
+			fmt.Printf("    Trade hold: %s\n", offer.Hold)
+		}
+		if offer.EscrowEnd != nil {
+			fmt.Printf("    Escrow until: %s\n", offer.EscrowEnd.Format("2006-01-02 15:04"))
+		}
+	}
+	fmt.Println()
+}
+
+// skipHeldTrades drops trades that would be held, or whose hold is unknown,
+// unless --allow-hold was given
+func skipHeldTrades(client *steamapi.Client, account *manifest.SteamGuardAccount, confirmations []*steamapi.Confirmation) []*steamapi.Confirmation {
+	if tradeAllowHold {
+		return confirmations
+	}
+	attachTradeOffers(client, account, confirmations)
+
+	kept := make([]*steamapi.Confirmation, 0, len(confirmations))
+	for _, conf := range confirmations {
+		if err := steamapi.CheckTradeHold(conf); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Skipping, %v (use --allow-hold to accept anyway)\n", err)
+			continue
+		}
+		kept = append(kept, conf)
+	}
+	return kept
+}
+
+// skipBelowFloor drops market listings below their price floor, or with an
+// unknown price, while price floors are set
+func skipBelowFloor(client *steamapi.Client, account *manifest.SteamGuardAccount, confirmations []*steamapi.Confirmation) []*steamapi.Confirmation {
+	floors := manifestMgr.GetPriceFloors()
+	if len(floors) == 0 {
+		return confirmations
+	}
+
+	kept := make([]*steamapi.Confirmation, 0, len(confirmations))
+	for _, conf := range confirmations {
+		details := func() (*steamapi.ConfirmationDetails, error) {
+			return client.GetConfirmationDetails(account, conf)
+		}
+		if err := market.CheckFloor(account.AccountName, conf, details, floors); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Skipping %s, %v\n", conf.ID, err)
+			continue
+		}
+		kept = append(kept, conf)
+	}
+	return kept
+}
+
+// skipHighRisk drops high-risk confirmations, which are only accepted on their own
+func skipHighRisk(account *manifest.SteamGuardAccount, confirmations []*steamapi.Confirmation) []*steamapi.Confirmation {
+	kept := make([]*steamapi.Confirmation, 0, len(confirmations))
+	for _, conf := range confirmations {
+		if err := steamapi.CheckRisk(conf); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Skipping, %v (accept it alone: steamguard -u %s trade accept %s)\n", err, account.AccountName, conf.ID)
+			continue
+		}
+		kept = append(kept, conf)
+	}
+	return kept
+}
+
+// confirmHighRisk makes the user type the account name before a high-risk
+// confirmation is accepted. Never passes without a terminal.
+func confirmHighRisk(account *manifest.SteamGuardAccount, conf *steamapi.Confirmation) bool {
+	if !isInteractive() {
+		fmt.Fprintf(os.Stderr, "Error: refusing to accept high-risk %s confirmation %s without a terminal\n", conf.Type, conf.ID)
+		return false
+	}
+	fmt.Printf("⚠️  This is a high-risk %s confirmation; accepting it can hand over %s.\n", conf.Type, account.AccountName)
+	fmt.Println("Only accept it if you started this yourself.")
+	return confirmTyped(fmt.Sprintf("Type the account name (%s) to accept: ", account.AccountName), account.AccountName)
+}
+
+// acceptHighRisk accepts one high-risk confirmation confirmed with
+// confirmHighRisk, returns the number of failures
+func acceptHighRisk(client *steamapi.Client, account *manifest.SteamGuardAccount, conf *steamapi.Confirmation) int {
+	results := []steamapi.ConfirmationResult{{Confirmation: conf, Err: client.AcceptConfirmation(account, conf)}}
+	return printConfirmationResults(client, account, results, true)
+}
+
+// summarizeItems describes items in one line
+func summarizeItems(items []steamapi.TradeItem) string {
+	if len(items) == 0 {
+		return "nothing"
+	}
+	names := make([]string, 0, 3)
+	for i, item := range items {
+		if i == 3 {
+			names = append(names, fmt.Sprintf("and %d more", len(items)-3))
+			break
+		}
+		name := item.Name
+		if name == "" {
+			name = fmt.Sprintf("class %s", item.ClassID)
+		}
+		names = append(names, name)
+	}
+	return fmt.Sprintf("%d item(s): %s", len(items), strings.Join(names, ", "))
+}
+
+// attachTradeOffers fetches the trade offers of confirmations, warning on failure
+func attachTradeOffers(client *steamapi.Client, account *manifest.SteamGuardAccount, confirmations []*steamapi.Confirmation) {
+	if err := client.AttachTradeOffers(account, confirmations); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+}
+
+var tradeAcceptCmd = &cobra.Command{
+	Use:   "accept [id...]",
+	Short: "Accept selected confirmations",
+	Long: `Accepts confirmations selected by confirmation IDs or creator IDs
+(trade offer or listing IDs) and filters. Shows a preview and asks before
+acting; use --yes for non-interactive use. Accepting every pending
+confirmation without IDs or filters needs --all-confirmations.
+
+Examples:
+  steamguard trade accept 1234567890
+  steamguard trade accept --all-confirmations
+  steamguard trade accept --type market --older-than 1h --yes
+  steamguard trade accept --match "Dota 2"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runTradeAction(args, true)
+	},
+}
+
+var tradeRejectCmd = &cobra.Command{
+	Use:   "reject [id...]",
+	Short: "Reject selected confirmations",
+	Long: `Rejects confirmations selected by confirmation IDs or creator IDs
+(trade offer or listing IDs) and filters. Shows a preview and asks before
+acting; use --yes for non-interactive use.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runTradeAction(args, false)
+	},
+}
+
+// accountConfirmations confirmations selected on one account
+type accountConfirmations struct {
+	account       *manifest.SteamGuardAccount
+	confirmations []*steamapi.Confirmation
+}
+
+// runTradeAction previews, confirms and applies accept/reject to the selected confirmations
+func runTradeAction(ids []string, accept bool) {
+	if manifestMgr.IsEmpty() {
+		fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
+		return
+	}
+
+	filter, err := buildConfirmationFilter(ids)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if accept && filter.IsEmpty() && !acceptEverything {
+		fmt.Fprintln(os.Stderr, "Error: give confirmation IDs, a filter (--type, --creator, --match, --older-than) or --all-confirmations to accept every pending confirmation")
+		os.Exit(1)
+	}
+	if acceptEverything && !filter.IsEmpty() {
+		fmt.Fprintln(os.Stderr, "Error: --all-confirmations can't be combined with IDs or filters")
+		os.Exit(1)
+	}
+
+	accounts, err := tradeAccounts()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := tradeClient()
+	report := &tradeReport{accounts: len(accounts)}
+
+	// Fetch and filter on the worker pool, then attach trade offers to the matches only
+	fetched := client.FetchConfirmations(accounts, steamapi.FetchOptions{Parallel: tradeParallel})
+	for i := range fetched {
+		if fetched[i].Err == nil {
+			fetched[i].Confirmations = filter.Apply(fetched[i].Confirmations)
+		}
+	}
+	steamapi.ForEachAccount(accounts, tradeParallel, func(i int, account *manifest.SteamGuardAccount) {
+		if fetched[i].Err == nil {
+			fetched[i].OfferErr = client.AttachTradeOffers(account, fetched[i].Confirmations)
+		}
+	})
+
+	// Preview everything before acting
+	var selected []accountConfirmations
+	total := 0
+	for _, result := range fetched {
+		account := result.Account
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get confirmations of %s: %v\n", account.AccountName, result.Err)
+			report.fail(account, "failed to get confirmations: %v", result.Err)
+			continue
+		}
+
+		matched := result.Confirmations
+		if len(matched) == 0 {
+			continue
+		}
+
+		if result.OfferErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", result.OfferErr)
+		}
+		fmt.Printf("=== %s ===\n", account.AccountName)
+		for i, conf := range matched {
+			printConfirmation(i+1, conf)
+		}
+		if accept {
+			if matched = skipBelowFloor(client, account, skipHeldTrades(client, account, matched)); len(matched) == 0 {
+				continue
+			}
+		}
+		selected = append(selected, accountConfirmations{account: account, confirmations: matched})
+		total += len(matched)
+	}
+
+	// High-risk confirmations are only accepted one at a time
+	var highRisk *accountConfirmations
+	if accept && total == 1 && steamapi.CheckRisk(selected[0].confirmations[0]) != nil {
+		highRisk = &selected[0]
+	} else if accept {
+		total = 0
+		for i := range selected {
+			selected[i].confirmations = skipHighRisk(selected[i].account, selected[i].confirmations)
+			total += len(selected[i].confirmations)
+		}
+	}
+
+	if total == 0 {
+		fmt.Println("No matching confirmations.")
+		if len(report.failures) > 0 {
+			if len(accounts) > 1 {
 				fmt.Println()
+				report.print("")
 			}
-			fmt.Println("Use --accept or --reject to manage confirmations.")
+			os.Exit(1)
 		}
-	},
+		return
+	}
+	report.confirmations = total
+
+	action, verb := "Reject", "reject"
+	if accept {
+		action, verb = "Accept", "accept"
+	}
+	if highRisk != nil {
+		// Typing the account name is required even with --yes
+		if !dryRun && !confirmHighRisk(highRisk.account, highRisk.confirmations[0]) {
+			fmt.Println("Cancelled.")
+			os.Exit(1)
+		}
+		if acceptHighRisk(client, highRisk.account, highRisk.confirmations[0]) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if !assumeYes && !dryRun {
+		if !isInteractive() {
+			fmt.Fprintln(os.Stderr, "Error: refusing to act without a terminal, use --yes")
+			os.Exit(1)
+		}
+		if !confirm(fmt.Sprintf("%s %d confirmation(s)?", action, total)) {
+			fmt.Println("Cancelled.")
+			return
+		}
+	}
+
+	for _, sel := range selected {
+		var results []steamapi.ConfirmationResult
+		if accept {
+			results = client.AcceptConfirmations(sel.account, sel.confirmations)
+		} else {
+			results = client.RejectConfirmations(sel.account, sel.confirmations)
+		}
+
+		report.results(sel.account, results, printConfirmationResults(client, sel.account, results, accept), verb)
+	}
+
+	if len(accounts) > 1 {
+		fmt.Println()
+		report.print(action + "ed")
+	}
+	if len(report.failures) > 0 {
+		os.Exit(1)
+	}
+}
+
+// buildConfirmationFilter builds a filter from IDs and the filter flags
+func buildConfirmationFilter(ids []string) (steamapi.ConfirmationFilter, error) {
+	filter := steamapi.ConfirmationFilter{
+		IDs:       ids,
+		Creators:  filterCreators,
+		OlderThan: filterOlderThan,
+	}
+
+	for _, name := range filterTypes {
+		confType, err := steamapi.ParseConfirmationType(name)
+		if err != nil {
+			return filter, err
+		}
+		filter.Types = append(filter.Types, confType)
+	}
+
+	if filterMatch != "" {
+		re, err := regexp.Compile(filterMatch)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --match: %w", err)
+		}
+		filter.Match = re
+	}
+
+	return filter, nil
+}
+
+// addFilterFlags registers the confirmation filter flags on a command
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&filterTypes, "type", nil, "Confirmation types: trade, market, apikey, phone, recovery, ...")
+	cmd.Flags().StringSliceVar(&filterCreators, "creator", nil, "Creator IDs (trade offer or listing IDs)")
+	cmd.Flags().StringVar(&filterMatch, "match", "", "Regular expression matched against headline and summary")
+	cmd.Flags().DurationVar(&filterOlderThan, "older-than", 0, "Only confirmations older than this (e.g. 30m, 2h)")
 }
 
 func init() {
 	rootCmd.AddCommand(tradeCmd)
-	tradeCmd.Flags().BoolVar(&acceptAll, "accept", false, "Accept all confirmations")
-	tradeCmd.Flags().BoolVar(&rejectAll, "reject", false, "Reject all confirmations")
-}
+	tradeCmd.AddCommand(tradeAcceptCmd)
+	tradeCmd.AddCommand(tradeRejectCmd)
+	tradeCmd.AddCommand(tradeShowCmd)
 
+	tradeShowCmd.Flags().BoolVar(&showJSON, "json", false, "Output as JSON")
+	tradeCmd.Flags().BoolVar(&listJSON, "json", false, "Print pending confirmations as JSON")
+	tradeCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Fetch and preview, but never accept or reject")
+	tradeCmd.Flags().BoolVar(&tradeAllowHold, "allow-hold", false, "Also accept trades that would be held")
+	tradeAcceptCmd.Flags().BoolVar(&tradeAllowHold, "allow-hold", false, "Also accept trades that would be held")
+	tradeAcceptCmd.Flags().BoolVar(&acceptEverything, "all-confirmations", false, "Accept every pending confirmation, without IDs or filters")
+	tradeCmd.PersistentFlags().BoolVar(&tradeAllAccounts, "all", false, "Handle every account")
+	tradeCmd.PersistentFlags().IntVar(&tradeParallel, "parallel", 8, "Accounts fetched at the same time")
+	tradeCmd.PersistentFlags().Float64Var(&tradeRate, "rate", 10, "Requests to Steam per second, 0 for no limit")
+
+	for _, cmd := range []*cobra.Command{tradeAcceptCmd, tradeRejectCmd} {
+		addFilterFlags(cmd)
+		cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation")
+	}
+
+	tradeCmd.Flags().BoolVar(&acceptAll, "accept", false, "Accept all confirmations, after a preview")
+	tradeCmd.Flags().BoolVar(&rejectAll, "reject", false, "Reject all confirmations, after a preview")
+	tradeCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Don't ask for confirmation with --accept or --reject")
+	tradeCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Pick confirmations to accept or reject from a list")
+}
@@ -0,0 +1,391 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	offerSent      bool
+	offerReceived  bool
+	offerJSON      bool
+	offerYes       bool
+	offerNoConfirm bool
+	offerAllowHold bool
+	offerTimeout   time.Duration
+)
+
+var tradeofferCmd = &cobra.Command{
+	Use:   "tradeoffer",
+	Short: "Manage incoming and sent trade offers",
+	Long: `Lists active trade offers with their items, and accepts, declines or
+cancels them with the session of the account. Offers are fetched through
+IEconService with the session's access token, or the account's Web API key
+(see 'meta --set-api-key').
+
+Accepting an offer that needs a mobile confirmation waits for the
+confirmation and accepts it too, unless --no-confirm is given. An offer
+whose trade would be held, or whose hold is unknown, is refused before it's
+accepted unless --allow-hold is given.
+
+Examples:
+  steamguard tradeoffer                     # Sent and received offers
+  steamguard tradeoffer --received --json
+  steamguard tradeoffer show 5566778899
+  steamguard tradeoffer accept 5566778899
+  steamguard tradeoffer decline 5566778899 --yes
+  steamguard tradeoffer cancel 5566778800
+  steamguard tradeoffer status 4433221100   # Status of the resulting trade`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if manifestMgr.IsEmpty() {
+			fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
+			return
+		}
+
+		accounts, err := selectAccounts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Without a filter, show both directions
+		query := steamapi.TradeOffersQuery{Sent: offerSent, Received: offerReceived, ActiveOnly: true}
+		if !offerSent && !offerReceived {
+			query.Sent, query.Received = true, true
+		}
+
+		client := newClient()
+		type accountOffers struct {
+			Sent     []*steamapi.TradeOffer `json:"sent,omitempty"`
+			Received []*steamapi.TradeOffer `json:"received,omitempty"`
+		}
+		output := make(map[string]accountOffers)
+		failed := false
+		for _, account := range accounts {
+			sent, received, err := client.GetTradeOffers(account, query)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get trade offers of %s: %v\n", account.AccountName, err)
+				failed = true
+				continue
+			}
+
+			if offerJSON {
+				output[account.AccountName] = accountOffers{Sent: sent, Received: received}
+				continue
+			}
+
+			fmt.Printf("=== %s ===\n", account.AccountName)
+			if len(sent) == 0 && len(received) == 0 {
+				fmt.Println("No active trade offers.")
+			}
+			for _, offer := range received {
+				printTradeOffer(offer)
+			}
+			for _, offer := range sent {
+				printTradeOffer(offer)
+			}
+			fmt.Println()
+		}
+
+		if offerJSON {
+			printOfferJSON(output)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var tradeofferShowCmd = &cobra.Command{
+	Use:   "show <offer-id>",
+	Short: "Show a trade offer with its items and trade hold",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		account, client := offerAccount()
+
+		offer, err := loadTradeOffer(client, account, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if offerJSON {
+			printOfferJSON(offer)
+			return
+		}
+		printTradeOffer(offer)
+	},
+}
+
+var tradeofferAcceptCmd = &cobra.Command{
+	Use:   "accept <offer-id>",
+	Short: "Accept an incoming trade offer and its mobile confirmation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		account, client := offerAccount()
+
+		offer, err := loadTradeOffer(client, account, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printTradeOffer(offer)
+		if !offerAllowHold {
+			if err := steamapi.CheckOfferHold(offer); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v, not accepted (use --allow-hold to accept anyway)\n", err)
+				os.Exit(1)
+			}
+		}
+		if !confirmOfferAction("Accept trade offer " + offer.ID + "?") {
+			fmt.Println("Cancelled.")
+			return
+		}
+
+		acceptance, err := client.AcceptTradeOffer(account, offer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Accepted trade offer %s\n", offer.ID)
+
+		failed := 0
+		switch {
+		case acceptance.NeedsMobileConfirmation && offerNoConfirm:
+			fmt.Printf("Needs a mobile confirmation: steamguard -u %s trade accept --creator %s\n", account.AccountName, offer.ID)
+		case acceptance.NeedsMobileConfirmation:
+			fmt.Println("Waiting for the mobile confirmation...")
+			conf, err := client.WaitForConfirmation(account, offer.ID, offerTimeout, 2*time.Second)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			// The hold may have changed since the offer was checked
+			if !offerAllowHold {
+				attachTradeOffers(client, account, []*steamapi.Confirmation{conf})
+				if err := steamapi.CheckTradeHold(conf); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v, left unconfirmed (use --allow-hold to accept anyway)\n", err)
+					os.Exit(1)
+				}
+			}
+			failed = printConfirmationResults(client, account, client.AcceptConfirmations(account, []*steamapi.Confirmation{conf}), true)
+		case acceptance.NeedsEmailConfirmation:
+			fmt.Println("Steam sent an email to confirm the trade.")
+		}
+
+		printOfferStatus(client, account, offer.ID, acceptance.TradeID)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+var tradeofferDeclineCmd = &cobra.Command{
+	Use:   "decline <offer-id>",
+	Short: "Decline an incoming trade offer",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runOfferAction(args[0], "Decline", "Declined", func(client *steamapi.Client, account *manifest.SteamGuardAccount, offer *steamapi.TradeOffer) error {
+			if offer.IsOurOffer {
+				return fmt.Errorf("trade offer %s was sent by this account, use 'tradeoffer cancel'", offer.ID)
+			}
+			return client.DeclineTradeOffer(account, offer.ID)
+		})
+	},
+}
+
+var tradeofferCancelCmd = &cobra.Command{
+	Use:   "cancel <offer-id>",
+	Short: "Cancel a trade offer sent by the account",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runOfferAction(args[0], "Cancel", "Cancelled", func(client *steamapi.Client, account *manifest.SteamGuardAccount, offer *steamapi.TradeOffer) error {
+			if !offer.IsOurOffer {
+				return fmt.Errorf("trade offer %s was received by this account, use 'tradeoffer decline'", offer.ID)
+			}
+			return client.CancelTradeOffer(account, offer.ID)
+		})
+	},
+}
+
+var tradeofferStatusCmd = &cobra.Command{
+	Use:   "status <trade-id>",
+	Short: "Show the status of a trade",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		account, client := offerAccount()
+
+		trade, err := client.GetTradeStatus(account, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if offerJSON {
+			printOfferJSON(trade)
+			return
+		}
+		printTrade(trade)
+	},
+}
+
+// offerAccount returns the single account offer commands work on and a client
+func offerAccount() (*manifest.SteamGuardAccount, *steamapi.Client) {
+	if manifestMgr.IsEmpty() {
+		fmt.Println("No accounts found. Use 'steamguard setup' to configure.")
+		os.Exit(1)
+	}
+
+	account, err := manifestMgr.GetAccount(username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return account, newClient()
+}
+
+// loadTradeOffer fetches an offer with the trade hold of its partner
+func loadTradeOffer(client *steamapi.Client, account *manifest.SteamGuardAccount, offerID string) (*steamapi.TradeOffer, error) {
+	offer, err := client.GetTradeOffer(account, offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer.State == steamapi.TradeOfferStateActive {
+		if hold, err := client.GetTradeHoldDurations(account, offer.PartnerSteamID); err == nil {
+			offer.Hold = hold
+		}
+	}
+	return offer, nil
+}
+
+// runOfferAction shows an offer, asks and runs a decline or cancel
+func runOfferAction(offerID, verb, done string, action func(*steamapi.Client, *manifest.SteamGuardAccount, *steamapi.TradeOffer) error) {
+	account, client := offerAccount()
+
+	offer, err := client.GetTradeOffer(account, offerID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	printTradeOffer(offer)
+	if !confirmOfferAction(fmt.Sprintf("%s trade offer %s?", verb, offer.ID)) {
+		fmt.Println("Cancelled.")
+		return
+	}
+
+	if err := action(client, account, offer); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ %s trade offer %s\n", done, offer.ID)
+	printOfferStatus(client, account, offer.ID, "")
+}
+
+// confirmOfferAction asks before acting on an offer, unless --yes was given
+func confirmOfferAction(question string) bool {
+	if offerYes {
+		return true
+	}
+	if !isInteractive() {
+		fmt.Fprintln(os.Stderr, "Error: refusing to act without a terminal, use --yes")
+		os.Exit(1)
+	}
+	return confirm(question)
+}
+
+// printOfferStatus prints the state of the offer and of its trade, if there is one
+func printOfferStatus(client *steamapi.Client, account *manifest.SteamGuardAccount, offerID, tradeID string) {
+	offer, err := client.GetTradeOffer(account, offerID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to check the trade offer: %v\n", err)
+		return
+	}
+	fmt.Printf("Offer state: %s\n", offer.State)
+
+	if tradeID == "" {
+		tradeID = offer.TradeID
+	}
+	if tradeID == "" {
+		return
+	}
+	trade, err := client.GetTradeStatus(account, tradeID)
+	if err != nil {
+		// Steam may not know the trade yet right after accepting
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to check trade %s: %v\n", tradeID, err)
+		return
+	}
+	fmt.Printf("Trade %s: %s\n", trade.ID, trade.Status)
+}
+
+// printTradeOffer prints one trade offer
+func printTradeOffer(offer *steamapi.TradeOffer) {
+	direction := "received from"
+	if offer.IsOurOffer {
+		direction = "sent to"
+	}
+	fmt.Printf("[%s] %s %s (%s)\n", offer.ID, direction, offer.PartnerSteamID, offer.State)
+	fmt.Printf("    Gives: %s\n", summarizeItems(offer.ItemsToGive))
+	fmt.Printf("    Receives: %s\n", summarizeItems(offer.ItemsToReceive))
+	if offer.Message != "" {
+		fmt.Printf("    Message: %s\n", offer.Message)
+	}
+	fmt.Printf("    Created: %s\n", offer.Created.Format("2006-01-02 15:04:05"))
+	if !offer.Expires.IsZero() && offer.State == steamapi.TradeOfferStateActive {
+		fmt.Printf("    Expires: %s\n", offer.Expires.Format("2006-01-02 15:04:05"))
+	}
+	if offer.Hold != nil {
+		fmt.Printf("    Trade hold: %s\n", offer.Hold)
+	}
+	if offer.EscrowEnd != nil {
+		fmt.Printf("    Escrow until: %s\n", offer.EscrowEnd.Format("2006-01-02 15:04"))
+	}
+	if offer.TradeID != "" {
+		fmt.Printf("    Trade: %s\n", offer.TradeID)
+	}
+}
+
+// printTrade prints a trade and its items
+func printTrade(trade *steamapi.Trade) {
+	fmt.Printf("Trade: %s\n", trade.ID)
+	fmt.Printf("Status: %s\n", trade.Status)
+	fmt.Printf("Partner: %s\n", trade.PartnerSteamID)
+	fmt.Printf("Created: %s\n", trade.Created.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Given: %s\n", summarizeItems(trade.Given))
+	fmt.Printf("Received: %s\n", summarizeItems(trade.Received))
+}
+
+// printOfferJSON prints a value as indented JSON
+func printOfferJSON(value interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
+
+func init() {
+	rootCmd.AddCommand(tradeofferCmd)
+	tradeofferCmd.AddCommand(tradeofferShowCmd)
+	tradeofferCmd.AddCommand(tradeofferAcceptCmd)
+	tradeofferCmd.AddCommand(tradeofferDeclineCmd)
+	tradeofferCmd.AddCommand(tradeofferCancelCmd)
+	tradeofferCmd.AddCommand(tradeofferStatusCmd)
+
+	tradeofferCmd.Flags().BoolVar(&offerSent, "sent", false, "Only offers sent by the account")
+	tradeofferCmd.Flags().BoolVar(&offerReceived, "received", false, "Only offers received by the account")
+	for _, cmd := range []*cobra.Command{tradeofferCmd, tradeofferShowCmd, tradeofferStatusCmd} {
+		cmd.Flags().BoolVar(&offerJSON, "json", false, "Output as JSON")
+	}
+	for _, cmd := range []*cobra.Command{tradeofferAcceptCmd, tradeofferDeclineCmd, tradeofferCancelCmd} {
+		cmd.Flags().BoolVarP(&offerYes, "yes", "y", false, "Don't ask for confirmation")
+	}
+	tradeofferAcceptCmd.Flags().BoolVar(&offerNoConfirm, "no-confirm", false, "Don't accept the mobile confirmation of the offer")
+	tradeofferAcceptCmd.Flags().BoolVar(&offerAllowHold, "allow-hold", false, "Also accept a trade offer that would be held")
+	tradeofferAcceptCmd.Flags().DurationVar(&offerTimeout, "timeout", 30*time.Second, "How long to wait for the mobile confirmation")
+}
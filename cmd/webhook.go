@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/notify"
+	"github.com/spf13/cobra"
+)
+
+var (
+	webhookSecret   string
+	webhookAccounts []string
+	webhookEvents   []string
+	webhookAttempts int
+)
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Manage webhooks notified about confirmations and sessions",
+	Long: `Webhooks receive a JSON event by POST whenever a confirmation appears,
+is accepted, rejected or fails, a session expires and can't be refreshed,
+or Steam stops accepting an authenticator.
+Webhooks are stored in notifications.json next to the manifest. A webhook
+without --account receives events of all accounts.
+
+Events: ` + strings.Join(notify.EventTypes, ", ") + `
+
+Every event is delivered once; its id stays the same across retries. The
+` + notify.HeaderSignature + ` header is "t=<unix time>,v1=<hex>", where v1 is
+the HMAC-SHA256 of "<unix time>.<body>" keyed with the webhook's secret.
+Failed deliveries (network errors, 429, 5xx) are retried with exponential
+backoff.
+
+Examples:
+  steamguard webhook add https://chat.example.com/hooks/steam
+  steamguard webhook add https://ops.example.com/bot1 --account bot1 --event confirmation.new
+  steamguard webhook
+  steamguard webhook test https://chat.example.com/hooks/steam
+  steamguard webhook remove https://ops.example.com/bot1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadNotifyConfig()
+		if len(cfg.Webhooks) == 0 {
+			fmt.Println("No webhooks. Use 'steamguard webhook add <url>' to add one.")
+			return
+		}
+
+		for _, webhook := range cfg.Webhooks {
+			fmt.Println(webhook.URL)
+			fmt.Printf("    Accounts: %s\n", listOrAll(webhook.Accounts))
+			fmt.Printf("    Events: %s\n", listOrAll(webhook.Events))
+			fmt.Printf("    Secret: %s…\n", webhook.Secret[:min(4, len(webhook.Secret))])
+		}
+	},
+}
+
+var webhookAddCmd = &cobra.Command{
+	Use:   "add <url>",
+	Short: "Add a webhook",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		url := args[0]
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			fmt.Fprintf(os.Stderr, "Error: %s is not an http(s) URL\n", url)
+			os.Exit(1)
+		}
+
+		filter := buildNotifyFilter(webhookAccounts, webhookEvents)
+
+		secret := webhookSecret
+		generated := secret == ""
+		if generated {
+			buf := make([]byte, 32)
+			if _, err := rand.Read(buf); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to generate secret: %v\n", err)
+				os.Exit(1)
+			}
+			secret = hex.EncodeToString(buf)
+		}
+
+		cfg := loadNotifyConfig()
+		for _, webhook := range cfg.Webhooks {
+			if webhook.URL == url {
+				fmt.Fprintf(os.Stderr, "Error: webhook %s already exists\n", url)
+				os.Exit(1)
+			}
+		}
+		cfg.Webhooks = append(cfg.Webhooks, notify.WebhookConfig{
+			URL:         url,
+			Secret:      secret,
+			Filter:      filter,
+			MaxAttempts: webhookAttempts,
+		})
+		saveNotifyConfig(cfg)
+
+		fmt.Printf("✓ Added webhook %s\n", url)
+		if generated {
+			fmt.Printf("Secret: %s\n", secret)
+		}
+	},
+}
+
+var webhookRemoveCmd = &cobra.Command{
+	Use:   "remove <url>",
+	Short: "Remove a webhook",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadNotifyConfig()
+		kept := cfg.Webhooks[:0]
+		for _, webhook := range cfg.Webhooks {
+			if webhook.URL != args[0] {
+				kept = append(kept, webhook)
+			}
+		}
+		if len(kept) == len(cfg.Webhooks) {
+			fmt.Fprintf(os.Stderr, "Error: webhook %s not found\n", args[0])
+			os.Exit(1)
+		}
+		cfg.Webhooks = kept
+		saveNotifyConfig(cfg)
+		fmt.Printf("✓ Removed webhook %s\n", args[0])
+	},
+}
+
+var webhookTestCmd = &cobra.Command{
+	Use:   "test [url]",
+	Short: "Send a test event to one or all webhooks",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadNotifyConfig()
+
+		event := notify.Event{
+			ID:   fmt.Sprintf("test-%d", time.Now().UnixNano()),
+			Type: "test",
+			Time: time.Now().UTC(),
+		}
+
+		sent, failed := 0, 0
+		for _, webhook := range cfg.Webhooks {
+			if len(args) == 1 && webhook.URL != args[0] {
+				continue
+			}
+			sent++
+			if err := notify.NewWebhook(webhook).Send(event); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", webhook.URL, err)
+				failed++
+				continue
+			}
+			fmt.Printf("✓ %s\n", webhook.URL)
+		}
+
+		if sent == 0 {
+			fmt.Fprintln(os.Stderr, "Error: no matching webhook")
+			os.Exit(1)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.AddCommand(webhookAddCmd)
+	webhookCmd.AddCommand(webhookRemoveCmd)
+	webhookCmd.AddCommand(webhookTestCmd)
+
+	webhookAddCmd.Flags().StringVar(&webhookSecret, "secret", "", "Shared signing secret (default: generated and printed)")
+	webhookAddCmd.Flags().StringSliceVar(&webhookAccounts, "account", nil, "Only events of these accounts (default: all)")
+	webhookAddCmd.Flags().StringSliceVar(&webhookEvents, "event", nil, "Only these events (default: all)")
+	webhookAddCmd.Flags().IntVar(&webhookAttempts, "max-attempts", 0, fmt.Sprintf("Delivery attempts before giving up (default %d)", notify.DefaultMaxAttempts))
+}
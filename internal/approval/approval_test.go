@@ -0,0 +1,355 @@
+package approval
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// testOperators returns identities and a config of the operators, signed by
+// all of them, protecting bot1
+func testOperators(t *testing.T, names ...string) (map[string]*Identity, *Config) {
+	t.Helper()
+	identities := make(map[string]*Identity)
+	config := &Config{ProtectedAccounts: []string{"bot1"}}
+	var signers []*Identity
+	for _, name := range names {
+		identity, operator, err := NewIdentity(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		identities[name] = identity
+		config.Operators = append(config.Operators, operator)
+		signers = append(signers, identity)
+	}
+	if err := config.Sign(&Config{}, signers); err != nil {
+		t.Fatal(err)
+	}
+	return identities, config
+}
+
+// testRequest returns a queue with a request by the operator
+func testRequest(t *testing.T, identity *Identity) (*Queue, *Request) {
+	t.Helper()
+	queue := &Queue{}
+	conf := &steamapi.Confirmation{ID: "111", CreatorID: "222", Type: steamapi.ConfTypeTrade, Headline: "Trade"}
+	request, err := queue.Add(identity, "bot1", conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queue, request
+}
+
+func TestQuorum(t *testing.T) {
+	operator := Operator{Name: "a"}
+	tests := []struct {
+		name   string
+		config Config
+		want   int
+	}{
+		{"no operators", Config{ProtectAll: true}, 0},
+		{"one operator", Config{Operators: []Operator{operator}, ProtectAll: true}, 1},
+		{"two operators, inactive", Config{Operators: []Operator{operator, operator}}, 1},
+		{"two operators, protected account", Config{Operators: []Operator{operator, operator}, ProtectedAccounts: []string{"bot1"}}, 2},
+		{"three operators, protected tag", Config{Operators: []Operator{operator, operator, operator}, ProtectedTags: []string{"vault"}}, 2},
+	}
+	for _, tt := range tests {
+		if got := tt.config.Quorum(); got != tt.want {
+			t.Errorf("%s: Quorum() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSignNeedsQuorumOfPrevious(t *testing.T) {
+	identities, config := testOperators(t, "alice", "bob")
+	mallory, _, err := NewIdentity("mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		signers []*Identity
+		wantErr bool
+	}{
+		{"both operators", []*Identity{identities["alice"], identities["bob"]}, false},
+		{"one operator", []*Identity{identities["alice"]}, true},
+		{"same operator twice", []*Identity{identities["alice"], identities["alice"]}, true},
+		{"operator and unknown key", []*Identity{identities["alice"], mallory}, true},
+		{"no signers", nil, true},
+	}
+	for _, tt := range tests {
+		changed := *config
+		changed.ProtectedAccounts = nil
+		changed.ProtectAll = true
+		err := changed.Sign(config, tt.signers)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Sign error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadConfigRejectsHandEdits(t *testing.T) {
+	_, config := testOperators(t, "alice", "bob")
+	mallory, malloryOperator, err := NewIdentity("mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		edit   func(c *Config)
+		wantOK bool
+	}{
+		{"unchanged", func(c *Config) {}, true},
+		{"protection lifted", func(c *Config) { c.ProtectedAccounts = nil }, false},
+		{"window changed", func(c *Config) { c.WindowMinutes = 600 }, false},
+		{"tagged account dropped", func(c *Config) { c.TaggedAccounts = nil; c.ProtectedTags = []string{"vault"} }, false},
+		{"operator added", func(c *Config) { c.Operators = append(c.Operators, malloryOperator) }, false},
+		{"signature removed", func(c *Config) { c.Signatures = c.Signatures[:1] }, false},
+		{"signature duplicated", func(c *Config) { c.Signatures = []ConfigSignature{c.Signatures[0], c.Signatures[0]} }, false},
+		{"signature by an unknown key", func(c *Config) {
+			message, _ := c.message()
+			c.Signatures[1] = ConfigSignature{Operator: "bob", Signature: mallory.sign(message)}
+		}, false},
+		{"operators emptied", func(c *Config) { c.Operators = nil; c.Signatures = nil }, false},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if err := SaveConfig(dir, config); err != nil {
+			t.Fatal(err)
+		}
+
+		var edited Config
+		data, _ := json.Marshal(config)
+		json.Unmarshal(data, &edited)
+		tt.edit(&edited)
+		data, _ = json.Marshal(&edited)
+		if err := os.WriteFile(filepath.Join(dir, ConfigFilename), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := LoadConfig(dir)
+		if tt.wantOK && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !tt.wantOK && err == nil {
+			t.Errorf("%s: LoadConfig accepted the edited config %+v", tt.name, loaded)
+		}
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	_, config := testOperators(t, "alice")
+
+	// Not set up yet
+	dir := t.TempDir()
+	if config, err := LoadConfig(dir); err != nil || len(config.Operators) != 0 {
+		t.Errorf("fresh directory: %+v, %v; want an empty config", config, err)
+	}
+
+	// Removed after setup
+	if err := SaveConfig(dir, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, ConfigFilename)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(dir); err == nil {
+		t.Error("LoadConfig passed with operators.json removed after setup")
+	}
+
+	// Removed along with the marker, while requests exist
+	os.Remove(filepath.Join(dir, MarkerFilename))
+	if err := SaveQueue(dir, &Queue{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(dir); err == nil {
+		t.Error("LoadConfig passed with operators.json removed and approvals.json present")
+	}
+
+	// The last operator can't be removed
+	if err := SaveConfig(t.TempDir(), &Config{}); err == nil {
+		t.Error("SaveConfig wrote a config without operators")
+	}
+}
+
+func TestRequestCheck(t *testing.T) {
+	identities, config := testOperators(t, "alice", "bob", "carol")
+	mallory, _, err := NewIdentity("mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		prepare func(q *Queue, r *Request)
+		at      time.Time
+		// wantApproval error wraps steamapi.ErrApprovalRequired
+		wantOK, wantApproval bool
+	}{
+		{"not approved", func(q *Queue, r *Request) {}, now, false, true},
+		{"approved by a second operator", func(q *Queue, r *Request) {
+			q.Approve(identities["bob"], r, config)
+		}, now, true, false},
+		{"approved by the requester", func(q *Queue, r *Request) {
+			approval := Approval{Operator: "alice", At: r.RequestedAt}
+			approval.Signature = identities["alice"].sign(r.approvalMessage(approval))
+			r.Approvals = append(r.Approvals, approval)
+		}, now, false, true},
+		{"approval forged with an unknown key", func(q *Queue, r *Request) {
+			approval := Approval{Operator: "bob", At: r.RequestedAt}
+			approval.Signature = mallory.sign(r.approvalMessage(approval))
+			r.Approvals = append(r.Approvals, approval)
+		}, now, false, true},
+		{"approval copied from another request", func(q *Queue, r *Request) {
+			_, other := testRequest(t, identities["carol"])
+			q.Approve(identities["bob"], other, config)
+			r.Approvals = append(r.Approvals, other.Approvals...)
+		}, now, false, true},
+		{"approval moved to another operator", func(q *Queue, r *Request) {
+			q.Approve(identities["bob"], r, config)
+			r.Approvals[0].Operator = "carol"
+		}, now, false, true},
+		{"approval after the window", func(q *Queue, r *Request) {
+			approval := Approval{Operator: "bob", At: r.RequestedAt.Add(2 * DefaultWindow)}
+			approval.Signature = identities["bob"].sign(r.approvalMessage(approval))
+			r.Approvals = append(r.Approvals, approval)
+		}, now, false, true},
+		{"expired", func(q *Queue, r *Request) {
+			q.Approve(identities["bob"], r, config)
+		}, now.Add(2 * DefaultWindow), false, true},
+		{"request edited", func(q *Queue, r *Request) {
+			q.Approve(identities["bob"], r, config)
+			r.ConfirmationID = "999"
+		}, now, false, false},
+		{"request by an unknown operator", func(q *Queue, r *Request) {
+			q.Approve(identities["bob"], r, config)
+			r.RequestedBy = "mallory"
+			r.Signature = mallory.sign(r.message())
+		}, now, false, false},
+		{"executed", func(q *Queue, r *Request) {
+			q.Approve(identities["bob"], r, config)
+			r.Close(StatusExecuted)
+		}, now, false, false},
+	}
+	for _, tt := range tests {
+		queue, request := testRequest(t, identities["alice"])
+		tt.prepare(queue, request)
+
+		err := request.Check(config, tt.at)
+		if tt.wantOK {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: Check passed", tt.name)
+			continue
+		}
+		if got := errors.Is(err, steamapi.ErrApprovalRequired); got != tt.wantApproval {
+			t.Errorf("%s: error %q wraps ErrApprovalRequired = %v, want %v", tt.name, err, got, tt.wantApproval)
+		}
+	}
+}
+
+func TestApprove(t *testing.T) {
+	identities, config := testOperators(t, "alice", "bob")
+	mallory, _, err := NewIdentity("mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		want     string
+	}{
+		{"requester", identities["alice"], "other than alice"},
+		{"requester, other case", &Identity{Name: "ALICE", key: identities["alice"].key}, "other than alice"},
+		{"unknown operator", mallory, "unknown operator"},
+	}
+	for _, tt := range tests {
+		queue, request := testRequest(t, identities["alice"])
+		err := queue.Approve(tt.identity, request, config)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	identities, config := testOperators(t, "alice", "bob")
+	mallory, _, err := NewIdentity("mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue, request := testRequest(t, identities["alice"])
+	if err := queue.Cancel(mallory, request, config); err == nil {
+		t.Error("an unknown operator cancelled the request")
+	}
+	if request.Status != StatusPending {
+		t.Fatalf("status %s after a refused cancel", request.Status)
+	}
+
+	if err := queue.Cancel(identities["bob"], request, config); err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != StatusCancelled || request.CheckCancellation(config) != nil {
+		t.Errorf("cancelled request: status %s, %v", request.Status, request.CheckCancellation(config))
+	}
+	if err := queue.Cancel(identities["alice"], request, config); err == nil {
+		t.Error("a cancelled request was cancelled again")
+	}
+
+	// Cancelled by editing approvals.json
+	_, forged := testRequest(t, identities["alice"])
+	forged.Close(StatusCancelled)
+	if forged.CheckCancellation(config) == nil {
+		t.Error("unsigned cancellation passed")
+	}
+	forged.Cancellation = &Approval{Operator: "bob", At: time.Now()}
+	forged.Cancellation.Signature = mallory.sign(forged.cancelMessage(*forged.Cancellation))
+	if forged.CheckCancellation(config) == nil {
+		t.Error("cancellation forged with an unknown key passed")
+	}
+}
+
+func TestProtects(t *testing.T) {
+	config := &Config{
+		ProtectedAccounts: []string{"vault"},
+		ProtectedTags:     []string{"high-value"},
+		TaggedAccounts:    []string{"bot1"},
+	}
+	tests := []struct {
+		account string
+		tags    []string
+		want    bool
+	}{
+		{"vault", nil, true},
+		{"VAULT", nil, true},
+		{"bot1", nil, true}, // tag removed after the policy was signed
+		{"bot2", []string{"High-Value"}, true},
+		{"bot2", []string{"market"}, false},
+		{"bot3", nil, false},
+	}
+	for _, tt := range tests {
+		if got := config.Protects(tt.account, tt.tags); got != tt.want {
+			t.Errorf("Protects(%s, %v) = %v, want %v", tt.account, tt.tags, got, tt.want)
+		}
+	}
+
+	all := &Config{ProtectAll: true}
+	if !all.Protects("anything", nil) {
+		t.Error("ProtectAll doesn't protect every account")
+	}
+}
@@ -0,0 +1,335 @@
+package approval
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// File names in the maFiles directory
+const (
+	ConfigFilename = "operators.json"
+	QueueFilename  = "approvals.json"
+	// MarkerFilename written with operators.json, so a missing
+	// operators.json is noticed once approval was set up
+	MarkerFilename = ".operators"
+)
+
+// DefaultWindow time the second operator has to approve a request
+const DefaultWindow = time.Hour
+
+// Operator an identity allowed to request and approve confirmations
+type Operator struct {
+	Name      string    `json:"name"`
+	PublicKey string    `json:"public_key"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// Config represents operators.json: the operators and which accounts need approval
+type Config struct {
+	Operators []Operator `json:"operators"`
+	// WindowMinutes time from the request to the approval and execution
+	WindowMinutes int `json:"window_minutes,omitempty"`
+	// ProtectAll every account needs approval
+	ProtectAll bool `json:"protect_all,omitempty"`
+	// ProtectedAccounts and ProtectedTags select the accounts needing approval
+	ProtectedAccounts []string `json:"protected_accounts,omitempty"`
+	ProtectedTags     []string `json:"protected_tags,omitempty"`
+	// TaggedAccounts the accounts carrying a protected tag when the policy
+	// was signed. Tags live in the unsigned steamguard.json, so removing a
+	// tag there doesn't lift the protection of these accounts.
+	TaggedAccounts []string `json:"tagged_accounts,omitempty"`
+	// Signatures of the operators who made the last change
+	Signatures []ConfigSignature `json:"signatures,omitempty"`
+}
+
+// ConfigSignature an operator's signature on the content of operators.json
+type ConfigSignature struct {
+	Operator  string `json:"operator"`
+	Signature string `json:"signature"`
+}
+
+// Active checks if any account needs approval
+func (c *Config) Active() bool {
+	return c.ProtectAll || len(c.ProtectedAccounts) > 0 || len(c.ProtectedTags) > 0
+}
+
+// Quorum returns how many of the config's operators must sign a change:
+// none without operators, two while the policy is active and there are two
+// operators to sign, one otherwise
+func (c *Config) Quorum() int {
+	switch {
+	case len(c.Operators) == 0:
+		return 0
+	case c.Active() && len(c.Operators) >= 2:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// message returns the signed content of the config
+func (c *Config) message() ([]byte, error) {
+	unsigned := *c
+	unsigned.Signatures = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode operators: %w", err)
+	}
+	return append([]byte("steamguard-operators:v1\n"), data...), nil
+}
+
+// Sign replaces the signatures with those of the identities. The change
+// must be signed by a quorum of the previous config's operators, and the
+// result by a quorum of its own so it passes the check of LoadConfig.
+func (c *Config) Sign(previous *Config, identities []*Identity) error {
+	message, err := c.message()
+	if err != nil {
+		return err
+	}
+
+	c.Signatures = nil
+	for _, identity := range identities {
+		c.Signatures = append(c.Signatures, ConfigSignature{
+			Operator:  identity.Name,
+			Signature: identity.sign(message),
+		})
+	}
+
+	if err := c.checkSignatures(previous); err != nil {
+		return fmt.Errorf("change not allowed: %w", err)
+	}
+	if err := c.checkSignatures(c); err != nil {
+		return fmt.Errorf("changed policy not allowed: %w", err)
+	}
+	return nil
+}
+
+// checkSignatures verifies the config carries signatures of a quorum of the
+// signers' operators
+func (c *Config) checkSignatures(signers *Config) error {
+	quorum := signers.Quorum()
+	if quorum == 0 {
+		return nil
+	}
+	message, err := c.message()
+	if err != nil {
+		return err
+	}
+
+	var valid []string
+	for _, sig := range c.Signatures {
+		if slices.ContainsFunc(valid, func(item string) bool { return strings.EqualFold(item, sig.Operator) }) {
+			continue
+		}
+		signature, _ := base64.StdEncoding.DecodeString(sig.Signature)
+		if signers.verify(sig.Operator, message, signature) == nil {
+			valid = append(valid, sig.Operator)
+		}
+	}
+	if len(valid) < quorum {
+		return fmt.Errorf("needs signatures of %d operator(s), has %d valid", quorum, len(valid))
+	}
+	return nil
+}
+
+// Window returns the approval window
+func (c *Config) Window() time.Duration {
+	if c.WindowMinutes <= 0 {
+		return DefaultWindow
+	}
+	return time.Duration(c.WindowMinutes) * time.Minute
+}
+
+// Protects checks if accepting on the account needs two-person approval.
+// The account's current tags can only add protection to the signed lists.
+func (c *Config) Protects(account string, tags []string) bool {
+	if c.ProtectAll || slices.ContainsFunc(c.ProtectedAccounts, func(item string) bool { return strings.EqualFold(item, account) }) || slices.ContainsFunc(c.TaggedAccounts, func(item string) bool { return strings.EqualFold(item, account) }) {
+		return true
+	}
+	return c.HasProtectedTag(tags)
+}
+
+// HasProtectedTag checks if any of the tags is protected
+func (c *Config) HasProtectedTag(tags []string) bool {
+	for _, tag := range c.ProtectedTags {
+		if slices.ContainsFunc(tags, func(item string) bool { return strings.EqualFold(item, tag) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// Operator returns the operator with the name
+func (c *Config) Operator(name string) (*Operator, bool) {
+	for i := range c.Operators {
+		if strings.EqualFold(c.Operators[i].Name, name) {
+			return &c.Operators[i], true
+		}
+	}
+	return nil, false
+}
+
+// verify checks a signature of the operator
+func (c *Config) verify(name string, message, signature []byte) error {
+	operator, ok := c.Operator(name)
+	if !ok {
+		return fmt.Errorf("unknown operator %q", name)
+	}
+	key, err := base64.StdEncoding.DecodeString(operator.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("operator %q has an invalid public key", name)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), message, signature) {
+		return fmt.Errorf("invalid signature of operator %q", name)
+	}
+	return nil
+}
+
+// LoadConfig reads operators.json; a missing file is an empty config until
+// approval is set up, after that it's an error. A config not signed by a
+// quorum of its operators, e.g. edited by hand, is an error.
+func LoadConfig(dir string) (*Config, error) {
+	config := &Config{}
+	data, err := os.ReadFile(filepath.Join(dir, ConfigFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		if set, err := wasSetUp(dir); err != nil || set {
+			if err == nil {
+				err = errors.New("two-person approval was set up")
+			}
+			return nil, fmt.Errorf("%s is missing in %s: %w; restore it from a backup", ConfigFilename, dir, err)
+		}
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operators: %w", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse operators: %w", err)
+	}
+	if len(config.Operators) == 0 {
+		return nil, fmt.Errorf("%s was changed outside steamguard: it has no operators", ConfigFilename)
+	}
+	if err := config.checkSignatures(config); err != nil {
+		return nil, fmt.Errorf("%s was changed outside steamguard: %w", ConfigFilename, err)
+	}
+	return config, nil
+}
+
+// SaveConfig writes operators.json, signed with Sign. The config keeps at
+// least one operator; approval is turned off by removing the files.
+func SaveConfig(dir string, config *Config) error {
+	if len(config.Operators) == 0 {
+		return fmt.Errorf("the last operator can't be removed, delete %s, %s and %s in %s to turn approval off",
+			ConfigFilename, MarkerFilename, QueueFilename, dir)
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode operators: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, MarkerFilename), nil, 0600); err != nil {
+		return fmt.Errorf("failed to write operators: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConfigFilename), data, 0600); err != nil {
+		return fmt.Errorf("failed to write operators: %w", err)
+	}
+	return nil
+}
+
+// wasSetUp checks if operators.json was written before, from the marker or
+// an approval queue
+func wasSetUp(dir string) (bool, error) {
+	for _, name := range []string{MarkerFilename, QueueFilename} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("failed to check %s: %w", name, err)
+		}
+	}
+	return false, nil
+}
+
+// Identity an operator's private key
+type Identity struct {
+	Name       string `json:"name"`
+	PrivateKey string `json:"private_key"`
+
+	key ed25519.PrivateKey
+}
+
+// NewIdentity generates a key pair for an operator
+func NewIdentity(name string) (*Identity, Operator, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, Operator{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	identity := &Identity{
+		Name:       name,
+		PrivateKey: base64.StdEncoding.EncodeToString(private),
+		key:        private,
+	}
+	operator := Operator{
+		Name:      name,
+		PublicKey: base64.StdEncoding.EncodeToString(public),
+		AddedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	return identity, operator, nil
+}
+
+// LoadIdentity reads an operator key file
+func LoadIdentity(path string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operator key: %w", err)
+	}
+
+	identity := &Identity{}
+	if err := json.Unmarshal(data, identity); err != nil {
+		return nil, fmt.Errorf("failed to parse operator key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(identity.PrivateKey)
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("operator key %s is invalid", path)
+	}
+	identity.key = ed25519.PrivateKey(key)
+	return identity, nil
+}
+
+// Save writes the key file, refusing to overwrite an existing one
+func (i *Identity) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode operator key: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write operator key: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write operator key: %w", err)
+	}
+	return nil
+}
+
+// sign signs a message with the operator's key
+func (i *Identity) sign(message []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(i.key, message))
+}
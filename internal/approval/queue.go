@@ -0,0 +1,299 @@
+package approval
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// Request statuses
+const (
+	StatusPending   = "pending"
+	StatusExecuted  = "executed"
+	StatusCancelled = "cancelled"
+)
+
+// Request a confirmation marked for acceptance, waiting for a second operator
+type Request struct {
+	ID             string     `json:"id"`
+	Account        string     `json:"account"`
+	ConfirmationID string     `json:"confirmation_id"`
+	CreatorID      string     `json:"creator_id,omitempty"`
+	Type           string     `json:"type"`
+	Headline       string     `json:"headline"`
+	RequestedBy    string     `json:"requested_by"`
+	RequestedAt    time.Time  `json:"requested_at"`
+	Signature      string     `json:"signature"`
+	Approvals      []Approval `json:"approvals,omitempty"`
+	Status         string     `json:"status"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	// Cancellation the signature of the operator who cancelled the request
+	Cancellation *Approval `json:"cancellation,omitempty"`
+}
+
+// Approval a second operator's signature on a request
+type Approval struct {
+	Operator  string    `json:"operator"`
+	At        time.Time `json:"at"`
+	Signature string    `json:"signature"`
+}
+
+// message returns the signed content of the request
+func (r *Request) message() []byte {
+	return []byte(fmt.Sprintf("steamguard-approval:v1\n%s\n%s\n%s\n%s\n%d",
+		r.ID, r.Account, r.ConfirmationID, r.CreatorID, r.RequestedAt.Unix()))
+}
+
+// approvalMessage returns the signed content of an approval
+func (r *Request) approvalMessage(approval Approval) []byte {
+	return append(r.message(), []byte(fmt.Sprintf("\napprove\n%s\n%d", approval.Operator, approval.At.Unix()))...)
+}
+
+// cancelMessage returns the signed content of a cancellation
+func (r *Request) cancelMessage(cancellation Approval) []byte {
+	return append(r.message(), []byte(fmt.Sprintf("\ncancel\n%s\n%d", cancellation.Operator, cancellation.At.Unix()))...)
+}
+
+// CheckCancellation verifies a cancelled request carries the signature of
+// an operator
+func (r *Request) CheckCancellation(config *Config) error {
+	if r.Status != StatusCancelled {
+		return nil
+	}
+	if r.Cancellation == nil {
+		return fmt.Errorf("approval request %s was cancelled without a signature", r.ID)
+	}
+	signature, _ := base64.StdEncoding.DecodeString(r.Cancellation.Signature)
+	if err := config.verify(r.Cancellation.Operator, r.cancelMessage(*r.Cancellation), signature); err != nil {
+		return fmt.Errorf("cancellation of approval request %s: %w", r.ID, err)
+	}
+	return nil
+}
+
+// Expired checks if the approval window has passed
+func (r *Request) Expired(config *Config, now time.Time) bool {
+	return now.After(r.RequestedAt.Add(config.Window()))
+}
+
+// Check verifies the request and returns nil when a distinct second
+// operator approved it within the window
+func (r *Request) Check(config *Config, now time.Time) error {
+	if r.Status != StatusPending {
+		return fmt.Errorf("approval request %s is %s", r.ID, r.Status)
+	}
+
+	signature, _ := base64.StdEncoding.DecodeString(r.Signature)
+	if err := config.verify(r.RequestedBy, r.message(), signature); err != nil {
+		return fmt.Errorf("approval request %s: %w", r.ID, err)
+	}
+
+	if r.Expired(config, now) {
+		return fmt.Errorf("%w: approval request %s expired at %s", steamapi.ErrApprovalRequired,
+			r.ID, r.RequestedAt.Add(config.Window()).Local().Format("2006-01-02 15:04:05"))
+	}
+
+	for _, approval := range r.Approvals {
+		if strings.EqualFold(approval.Operator, r.RequestedBy) {
+			continue
+		}
+		if approval.At.Before(r.RequestedAt) || approval.At.After(r.RequestedAt.Add(config.Window())) {
+			continue
+		}
+		signature, _ := base64.StdEncoding.DecodeString(approval.Signature)
+		if config.verify(approval.Operator, r.approvalMessage(approval), signature) == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: approval request %s by %s is waiting for a second operator", steamapi.ErrApprovalRequired, r.ID, r.RequestedBy)
+}
+
+// Queue represents approvals.json
+type Queue struct {
+	Requests []*Request `json:"requests"`
+}
+
+// LoadQueue reads approvals.json; a missing file is an empty queue
+func LoadQueue(dir string) (*Queue, error) {
+	queue := &Queue{}
+	data, err := os.ReadFile(filepath.Join(dir, QueueFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return queue, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approvals: %w", err)
+	}
+	if err := json.Unmarshal(data, queue); err != nil {
+		return nil, fmt.Errorf("failed to parse approvals: %w", err)
+	}
+	return queue, nil
+}
+
+// SaveQueue writes approvals.json
+func SaveQueue(dir string, queue *Queue) error {
+	data, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode approvals: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, QueueFilename), data, 0600); err != nil {
+		return fmt.Errorf("failed to write approvals: %w", err)
+	}
+	return nil
+}
+
+// Get returns the request with the ID or a unique ID prefix
+func (q *Queue) Get(id string) (*Request, error) {
+	var found *Request
+	for _, request := range q.Requests {
+		if request.ID == id {
+			return request, nil
+		}
+		if strings.HasPrefix(request.ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("approval request ID %q is ambiguous", id)
+			}
+			found = request
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("approval request %s not found", id)
+	}
+	return found, nil
+}
+
+// Pending returns the pending request for a confirmation
+func (q *Queue) Pending(account, confirmationID string) *Request {
+	for _, request := range q.Requests {
+		if request.Status == StatusPending && request.Account == account && request.ConfirmationID == confirmationID {
+			return request
+		}
+	}
+	return nil
+}
+
+// Add creates a request signed by the operator
+func (q *Queue) Add(identity *Identity, account string, conf *steamapi.Confirmation) (*Request, error) {
+	if existing := q.Pending(account, conf.ID); existing != nil {
+		return nil, fmt.Errorf("confirmation %s already has approval request %s", conf.ID, existing.ID)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate request ID: %w", err)
+	}
+
+	request := &Request{
+		ID:             hex.EncodeToString(id),
+		Account:        account,
+		ConfirmationID: conf.ID,
+		CreatorID:      conf.CreatorID,
+		Type:           conf.Type.String(),
+		Headline:       conf.Description(),
+		RequestedBy:    identity.Name,
+		RequestedAt:    time.Now().UTC().Truncate(time.Second),
+		Status:         StatusPending,
+	}
+	request.Signature = identity.sign(request.message())
+
+	q.Requests = append(q.Requests, request)
+	return request, nil
+}
+
+// Approve adds the operator's approval to a request
+func (q *Queue) Approve(identity *Identity, request *Request, config *Config) error {
+	if request.Status != StatusPending {
+		return fmt.Errorf("approval request %s is %s", request.ID, request.Status)
+	}
+	if strings.EqualFold(identity.Name, request.RequestedBy) {
+		return fmt.Errorf("approval request %s must be approved by an operator other than %s", request.ID, request.RequestedBy)
+	}
+	if _, ok := config.Operator(identity.Name); !ok {
+		return fmt.Errorf("unknown operator %q", identity.Name)
+	}
+	if request.Expired(config, time.Now()) {
+		return fmt.Errorf("approval request %s has expired", request.ID)
+	}
+
+	approval := Approval{Operator: identity.Name, At: time.Now().UTC().Truncate(time.Second)}
+	approval.Signature = identity.sign(request.approvalMessage(approval))
+	request.Approvals = append(request.Approvals, approval)
+	return nil
+}
+
+// Cancel closes a pending request, signed by a configured operator
+func (q *Queue) Cancel(identity *Identity, request *Request, config *Config) error {
+	if request.Status != StatusPending {
+		return fmt.Errorf("approval request %s is %s", request.ID, request.Status)
+	}
+	if _, ok := config.Operator(identity.Name); !ok {
+		return fmt.Errorf("unknown operator %q", identity.Name)
+	}
+
+	cancellation := Approval{Operator: identity.Name, At: time.Now().UTC().Truncate(time.Second)}
+	cancellation.Signature = identity.sign(request.cancelMessage(cancellation))
+	request.Cancellation = &cancellation
+	request.Close(StatusCancelled)
+	return nil
+}
+
+// Close marks a request as executed or cancelled
+func (r *Request) Close(status string) {
+	now := time.Now().UTC().Truncate(time.Second)
+	r.Status = status
+	r.ClosedAt = &now
+}
+
+// Gate requires two-person approval for accepting on protected accounts
+type Gate struct {
+	Dir     string
+	Manager *manifest.Manager
+}
+
+// CheckAccept implements steamapi.Approver
+func (g *Gate) CheckAccept(account *manifest.SteamGuardAccount, conf *steamapi.Confirmation) error {
+	config, err := LoadConfig(g.Dir)
+	if err != nil {
+		return err
+	}
+	if !config.Protects(account.AccountName, g.Manager.GetMetadata(account.AccountName).Tags) {
+		return nil
+	}
+
+	queue, err := LoadQueue(g.Dir)
+	if err != nil {
+		return err
+	}
+	request := queue.Pending(account.AccountName, conf.ID)
+	if request == nil {
+		return fmt.Errorf("%w: request it with 'steamguard -u %s approval request %s'",
+			steamapi.ErrApprovalRequired, account.AccountName, conf.ID)
+	}
+	return request.Check(config, time.Now())
+}
+
+// Accepted implements steamapi.Approver, closing the request of the confirmation
+func (g *Gate) Accepted(account *manifest.SteamGuardAccount, conf *steamapi.Confirmation) error {
+	queue, err := LoadQueue(g.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to close approval request: %w", err)
+	}
+	request := queue.Pending(account.AccountName, conf.ID)
+	if request == nil {
+		return nil
+	}
+	request.Close(StatusExecuted)
+	if err := SaveQueue(g.Dir, queue); err != nil {
+		return fmt.Errorf("failed to close approval request %s: %w", request.ID, err)
+	}
+	return nil
+}
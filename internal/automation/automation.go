@@ -0,0 +1,281 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/history"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/market"
+	"github.com/devhooly/steamguard-go/internal/notify"
+	"github.com/devhooly/steamguard-go/internal/rules"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// DefaultInterval is used when the manifest has no periodic checking interval
+const DefaultInterval = 60 * time.Second
+
+// Decision rule outcome for one confirmation
+type Decision struct {
+	Account      string
+	Confirmation *steamapi.Confirmation
+	Result       rules.Result
+	Err          error
+}
+
+// DetailsFunc loads the details of a confirmation for rules that need them
+type DetailsFunc func(conf *steamapi.Confirmation) (*steamapi.ConfirmationDetails, error)
+
+// Decide evaluates the rules for the confirmations of one account.
+// Trades that would be held, or whose hold is unknown, are only accepted
+// when allowHold is set or the matching rule allows it. While price floors
+// are set, market listings below their floor or with an unknown price are
+// never accepted.
+func Decide(ruleset []*rules.Rule, account string, meta manifest.AccountMeta, confs []*steamapi.Confirmation, details DetailsFunc, allowHold bool, floors map[string]float64) []Decision {
+	decisions := make([]Decision, 0, len(confs))
+	for _, conf := range confs {
+		conf := conf
+		in := &rules.Input{
+			Account:       account,
+			AccountTags:   meta.Tags,
+			AccountGroups: meta.Groups,
+			Confirmation:  conf,
+		}
+		if details != nil {
+			in.Details = func() (*steamapi.ConfirmationDetails, error) {
+				return details(conf)
+			}
+		}
+		decisions = append(decisions, DecideInput(ruleset, in, allowHold, floors))
+	}
+	return decisions
+}
+
+// DecideInput evaluates the rules for one confirmation like Decide. An
+// accept the guards turn into ignore keeps the rule in Result and has the
+// reason, wrapping steamapi.ErrHighRisk, steamapi.ErrTradeHeld or
+// market.ErrBelowFloor, in Err.
+func DecideInput(ruleset []*rules.Rule, in *rules.Input, allowHold bool, floors map[string]float64) Decision {
+	conf := in.Confirmation
+	result, err := rules.Evaluate(ruleset, in)
+	// High-risk confirmations are never accepted automatically
+	if err == nil && result.Action == rules.ActionAccept {
+		if riskErr := steamapi.CheckRisk(conf); riskErr != nil {
+			err = fmt.Errorf("%s: %w", result.Source(), riskErr)
+			result.Action = rules.ActionIgnore
+		}
+	}
+	if err == nil && result.Action == rules.ActionAccept && !allowHold && !ruleset[result.Rule].AllowHold {
+		if holdErr := steamapi.CheckTradeHold(conf); holdErr != nil {
+			err = fmt.Errorf("%s: %w", result.Source(), holdErr)
+			result.Action = rules.ActionIgnore
+		}
+	}
+	if err == nil && result.Action == rules.ActionAccept {
+		if floorErr := market.CheckFloor(in.Account, conf, in.LoadDetails, floors); floorErr != nil {
+			err = fmt.Errorf("%s: %w", result.Source(), floorErr)
+			result.Action = rules.ActionIgnore
+		}
+	}
+	return Decision{Account: in.Account, Confirmation: conf, Result: result, Err: err}
+}
+
+// Daemon polls confirmations of accounts and applies the auto-confirm rules
+type Daemon struct {
+	Manager *manifest.Manager
+	Client  *steamapi.Client
+	Logger  *log.Logger
+	// History records accepted and rejected confirmations, optional
+	History *history.Log
+	// Notifier receives confirmation and account events, optional
+	Notifier *notify.Dispatcher
+	Accounts []*manifest.SteamGuardAccount
+	Rules    []*rules.Rule
+	Interval time.Duration
+	// AllowHold accepts held trades without a rule allowing it
+	AllowHold bool
+	// Jitter is the maximum random deviation added to each interval
+	Jitter time.Duration
+}
+
+// Run polls until the context is cancelled. Errors of single accounts are
+// logged and retried on the next round.
+func (d *Daemon) Run(ctx context.Context) error {
+	if d.Interval <= 0 {
+		d.Interval = DefaultInterval
+	}
+
+	d.Logger.Printf("started: %d account(s), %d rule(s), interval %s ± %s",
+		len(d.Accounts), len(d.Rules), d.Interval, d.Jitter)
+
+	for {
+		d.Poll()
+
+		wait := d.nextWait()
+		select {
+		case <-ctx.Done():
+			d.Logger.Printf("stopped")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// nextWait returns the interval with random jitter applied
+func (d *Daemon) nextWait() time.Duration {
+	wait := d.Interval
+	if d.Jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(2*d.Jitter))) - d.Jitter
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
+
+// Poll checks every account once
+func (d *Daemon) Poll() {
+	for _, account := range d.Accounts {
+		if err := d.pollAccount(account); err != nil {
+			d.Logger.Printf("%s: %v", account.AccountName, err)
+		}
+	}
+	if !d.Client.DryRun {
+		d.Notifier.Flush(false)
+	}
+}
+
+// pollAccount fetches, decides and responds to the confirmations of one account
+func (d *Daemon) pollAccount(account *manifest.SteamGuardAccount) error {
+	if err := account.CheckEnrolled(); err != nil {
+		return err
+	}
+
+	// Renew ahead of time instead of waiting for Steam to reject the session
+	if account.Session.RefreshToken != "" && account.Session.AccessTokenExpired() {
+		if err := d.refreshSession(account); err != nil {
+			return err
+		}
+	}
+
+	confirmations, err := d.Client.GetConfirmations(account)
+	if errors.Is(err, steamapi.ErrNeedAuth) {
+		if err := d.refreshSession(account); err != nil {
+			return err
+		}
+		confirmations, err = d.Client.GetConfirmations(account)
+	}
+	if errors.Is(err, steamapi.ErrAuthenticatorRevoked) {
+		d.notify(notify.AuthenticatorRevokedEvent(account, err))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get confirmations: %w", err)
+	}
+
+	// Confirmations already reported are dropped by the notifier
+	events := make([]notify.Event, 0, len(confirmations))
+	for _, conf := range confirmations {
+		events = append(events, notify.ConfirmationEvent(notify.EventConfirmationNew, account.AccountName, conf))
+	}
+	d.notify(events...)
+
+	// Rules reason about the offer contents, a missing offer only makes those rules not match
+	if err := d.Client.AttachTradeOffers(account, confirmations); err != nil {
+		d.Logger.Printf("%s: %v", account.AccountName, err)
+	}
+
+	decisions := Decide(d.Rules, account.AccountName, d.Manager.GetMetadata(account.AccountName), confirmations,
+		func(conf *steamapi.Confirmation) (*steamapi.ConfirmationDetails, error) {
+			return d.Client.GetConfirmationDetails(account, conf)
+		}, d.AllowHold, d.Manager.GetPriceFloors())
+
+	var accept, reject []*steamapi.Confirmation
+	sources := make(map[string]string)
+	for _, decision := range decisions {
+		conf := decision.Confirmation
+		if decision.Err != nil {
+			// Leave it alone, it's evaluated again on the next round
+			d.Logger.Printf("%s: ignore %s: %v", account.AccountName, conf.ID, decision.Err)
+			continue
+		}
+
+		d.Logger.Printf("%s: %s %s (%s, creator %s) %q [%s]",
+			account.AccountName, decision.Result.Action, conf.ID, conf.Type, conf.CreatorID, conf.Description(), decision.Result.Source())
+
+		sources[conf.ID] = decision.Result.Source()
+		switch decision.Result.Action {
+		case rules.ActionAccept:
+			accept = append(accept, conf)
+		case rules.ActionReject:
+			reject = append(reject, conf)
+		}
+	}
+
+	d.logResults(account, rules.ActionAccept, d.Client.AcceptConfirmations(account, accept), sources)
+	d.logResults(account, rules.ActionReject, d.Client.RejectConfirmations(account, reject), sources)
+	return nil
+}
+
+// logResults logs and records the outcome of responding to confirmations
+func (d *Daemon) logResults(account *manifest.SteamGuardAccount, action rules.Action, results []steamapi.ConfirmationResult, sources map[string]string) {
+	verb := action.String() + "ed"
+	if d.Client.DryRun {
+		verb = "would " + action.String()
+	}
+
+	eventType := notify.EventConfirmationAccepted
+	if action == rules.ActionReject {
+		eventType = notify.EventConfirmationRejected
+	}
+
+	var entries []history.Entry
+	var events []notify.Event
+	for _, result := range results {
+		conf := result.Confirmation
+		if result.Err != nil {
+			d.Logger.Printf("%s: failed to %s %s: %v", account.AccountName, action, conf.ID, result.Err)
+			event := notify.ConfirmationEvent(notify.EventConfirmationFailed, account.AccountName, conf)
+			event.Source, event.Detail, event.Error = history.SourceRule, action.String()+": "+sources[conf.ID], result.Err.Error()
+			events = append(events, event)
+		} else {
+			d.Logger.Printf("%s: %s %s", account.AccountName, verb, conf.ID)
+			event := notify.ConfirmationEvent(eventType, account.AccountName, conf)
+			event.Source, event.Detail = history.SourceRule, sources[conf.ID]
+			events = append(events, event)
+		}
+		entries = append(entries, history.NewEntry(account.AccountName, conf, action.String(), history.SourceRule, sources[conf.ID], result.Err))
+	}
+	d.notify(events...)
+
+	if d.History != nil && !d.Client.DryRun {
+		if err := d.History.Append(entries...); err != nil {
+			d.Logger.Printf("%s: failed to record history: %v", account.AccountName, err)
+		}
+	}
+}
+
+// refreshSession renews the access token of an expired session
+func (d *Daemon) refreshSession(account *manifest.SteamGuardAccount) error {
+	if err := d.Client.RefreshAccessToken(&account.Session); err != nil {
+		err = fmt.Errorf("session expired and could not be refreshed: %w", err)
+		d.notify(notify.SessionExpiredEvent(account, err))
+		return err
+	}
+	if err := d.Manager.UpdateAccount(account); err != nil {
+		return fmt.Errorf("failed to save refreshed session: %w", err)
+	}
+	d.Logger.Printf("%s: session refreshed", account.AccountName)
+	return nil
+}
+
+// notify dispatches events, except in dry runs
+func (d *Daemon) notify(events ...notify.Event) {
+	if d.Client.DryRun {
+		return
+	}
+	d.Notifier.Dispatch(events...)
+}
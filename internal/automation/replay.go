@@ -0,0 +1,66 @@
+package automation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// ReplaySet saved confirmations of one account
+type ReplaySet struct {
+	// Account is empty when the file doesn't say which account the confirmations belong to
+	Account       string
+	Confirmations []*steamapi.Confirmation
+}
+
+// LoadReplay reads saved confirmations. The file holds a JSON array of
+// confirmations, a mobileconf/getlist response, or an object mapping
+// account names to arrays of confirmations as written by 'trade --json'.
+func LoadReplay(path string) ([]ReplaySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read confirmations: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		var confs []*steamapi.Confirmation
+		if err := json.Unmarshal(data, &confs); err != nil {
+			return nil, fmt.Errorf("failed to parse confirmations: %w", err)
+		}
+		return []ReplaySet{{Confirmations: confs}}, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("failed to parse confirmations: %w", err)
+	}
+
+	if raw, ok := object["conf"]; ok {
+		var confs []*steamapi.Confirmation
+		if err := json.Unmarshal(raw, &confs); err != nil {
+			return nil, fmt.Errorf("failed to parse confirmations: %w", err)
+		}
+		return []ReplaySet{{Confirmations: confs}}, nil
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sets := make([]ReplaySet, 0, len(names))
+	for _, name := range names {
+		var confs []*steamapi.Confirmation
+		if err := json.Unmarshal(object[name], &confs); err != nil {
+			return nil, fmt.Errorf("failed to parse confirmations of %s: %w", name, err)
+		}
+		sets = append(sets, ReplaySet{Account: name, Confirmations: confs})
+	}
+	return sets, nil
+}
@@ -0,0 +1,358 @@
+package doctor
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/crypto"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+const (
+	// secretSize length of decoded shared and identity secrets
+	secretSize = 20
+	// driftWarning clock drift that makes codes unreliable
+	driftWarning = 10 * time.Second
+	// driftError clock drift that makes codes invalid
+	driftError = 30 * time.Second
+	// steamID64Base the lowest individual account SteamID64
+	steamID64Base = 76561197960265728
+)
+
+var deviceIDPattern = regexp.MustCompile(`^android:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// checkPermissions checks that the directory and files are private
+func (d *Doctor) checkPermissions() {
+	// Windows doesn't have Unix permission bits
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	info, err := os.Stat(d.Path)
+	if err != nil {
+		d.add(Finding{Severity: SeverityError, Check: "permissions", Subject: d.Path, Message: err.Error()})
+		return
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		path := d.Path
+		d.add(Finding{
+			Severity:       SeverityWarning,
+			Check:          "permissions",
+			Subject:        path,
+			Message:        fmt.Sprintf("directory is accessible by other users (%s)", info.Mode().Perm()),
+			FixDescription: "chmod 700",
+			Fix:            func() error { return os.Chmod(path, 0700) },
+		})
+	}
+
+	files, err := os.ReadDir(d.Path)
+	if err != nil {
+		d.add(Finding{Severity: SeverityError, Check: "permissions", Subject: d.Path, Message: err.Error()})
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() || (!isMaFile(file.Name()) && file.Name() != "manifest.json") {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			path := filepath.Join(d.Path, file.Name())
+			d.add(Finding{
+				Severity:       SeverityWarning,
+				Check:          "permissions",
+				Subject:        file.Name(),
+				Message:        fmt.Sprintf("file is accessible by other users (%s)", info.Mode().Perm()),
+				FixDescription: "chmod 600",
+				Fix:            func() error { return os.Chmod(path, 0600) },
+			})
+		}
+	}
+}
+
+// checkEntries checks that manifest entries and .maFile files match both ways
+func (d *Doctor) checkEntries() {
+	listed := make(map[string]bool)
+	for _, entry := range d.manifest.Entries {
+		listed[strings.ToLower(entry.Filename)] = true
+
+		if _, err := os.Stat(filepath.Join(d.Path, entry.Filename)); os.IsNotExist(err) {
+			filename := entry.Filename
+			d.add(Finding{
+				Severity:       SeverityError,
+				Check:          "entries",
+				Subject:        filename,
+				Message:        "listed in manifest but the file does not exist",
+				FixDescription: "remove the entry from manifest.json",
+				Fix:            func() error { return d.removeEntry(filename) },
+			})
+		}
+	}
+
+	files, err := os.ReadDir(d.Path)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() || !isMaFile(file.Name()) || listed[strings.ToLower(file.Name())] {
+			continue
+		}
+
+		f := Finding{
+			Severity: SeverityWarning,
+			Check:    "entries",
+			Subject:  file.Name(),
+			Message:  "file exists but is not listed in manifest",
+		}
+		// Only plain text files can be added back, encrypted ones need their IV and salt
+		if !d.manifest.Encrypted {
+			filename := file.Name()
+			f.FixDescription = "add an entry to manifest.json"
+			f.Fix = func() error { return d.addEntry(filename) }
+		}
+		d.add(f)
+	}
+}
+
+// checkSecrets checks that the secrets are valid base64 of the right length
+func (d *Doctor) checkSecrets(acc accountFile) {
+	secrets := []struct {
+		name     string
+		value    string
+		required bool
+	}{
+		{"shared_secret", acc.account.SharedSecret, true},
+		{"identity_secret", acc.account.IdentitySecret, true},
+		{"secret_1", acc.account.Secret1, false},
+	}
+
+	for _, secret := range secrets {
+		if secret.value == "" {
+			if secret.required {
+				d.add(Finding{Severity: SeverityError, Check: "secrets", Subject: subject(acc),
+					Message: secret.name + " is missing"})
+			}
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(secret.value)
+		if err != nil {
+			d.add(Finding{Severity: SeverityError, Check: "secrets", Subject: subject(acc),
+				Message: fmt.Sprintf("%s is not valid base64: %v", secret.name, err)})
+			continue
+		}
+		if secret.required && len(decoded) != secretSize {
+			d.add(Finding{Severity: SeverityError, Check: "secrets", Subject: subject(acc),
+				Message: fmt.Sprintf("%s decodes to %d bytes, expected %d", secret.name, len(decoded), secretSize)})
+		}
+	}
+
+	if acc.account.RevocationCode == "" {
+		d.add(Finding{Severity: SeverityWarning, Check: "secrets", Subject: subject(acc),
+			Message: "revocation code is missing"})
+	}
+	if !acc.account.FullyEnrolled {
+		d.add(Finding{Severity: SeverityWarning, Check: "secrets", Subject: subject(acc),
+			Message: "authenticator setup was not finished, run 'steamguard setup --resume'"})
+	}
+}
+
+// checkIdentity checks that SteamID, device ID and URI agree
+func (d *Doctor) checkIdentity(acc accountFile) {
+	account := acc.account
+	entry := d.entry(acc.filename)
+
+	var steamID uint64
+	if _, err := fmt.Sscan(account.Session.SteamID, &steamID); err != nil || steamID < steamID64Base {
+		d.add(Finding{Severity: SeverityError, Check: "identity", Subject: subject(acc),
+			Message: fmt.Sprintf("invalid SteamID %q in session", account.Session.SteamID)})
+	} else if entry.SteamID != account.Session.SteamID {
+		d.add(Finding{
+			Severity:       SeverityWarning,
+			Check:          "identity",
+			Subject:        subject(acc),
+			Message:        fmt.Sprintf("manifest SteamID %q differs from account SteamID %q", entry.SteamID, account.Session.SteamID),
+			FixDescription: "update the manifest entry",
+			Fix: func() error {
+				entry := d.entry(acc.filename)
+				if entry == nil {
+					return fmt.Errorf("entry %s no longer exists", acc.filename)
+				}
+				entry.SteamID = account.Session.SteamID
+				return d.saveManifest()
+			},
+		})
+	}
+
+	if account.DeviceID == "" {
+		d.add(Finding{Severity: SeverityWarning, Check: "identity", Subject: subject(acc),
+			Message: "device ID is missing"})
+	} else if !deviceIDPattern.MatchString(account.DeviceID) {
+		d.add(Finding{Severity: SeverityWarning, Check: "identity", Subject: subject(acc),
+			Message: fmt.Sprintf("device ID %q is not in android:<uuid> format", account.DeviceID)})
+	}
+
+	if account.URI == "" {
+		return
+	}
+	uri, err := url.Parse(account.URI)
+	if err != nil {
+		d.add(Finding{Severity: SeverityWarning, Check: "identity", Subject: subject(acc),
+			Message: fmt.Sprintf("URI is invalid: %v", err)})
+		return
+	}
+
+	label := strings.TrimPrefix(strings.TrimPrefix(uri.Path, "/"), "Steam:")
+	if label != "" && !strings.EqualFold(label, account.AccountName) {
+		d.add(Finding{Severity: SeverityWarning, Check: "identity", Subject: subject(acc),
+			Message: fmt.Sprintf("URI is issued for %q, not %q", label, account.AccountName)})
+	}
+
+	uriSecret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(strings.TrimRight(uri.Query().Get("secret"), "=")))
+	sharedSecret, sharedErr := base64.StdEncoding.DecodeString(account.SharedSecret)
+	if err == nil && sharedErr == nil && string(uriSecret) != string(sharedSecret) {
+		d.add(Finding{Severity: SeverityError, Check: "identity", Subject: subject(acc),
+			Message: "URI secret does not match shared_secret"})
+	}
+}
+
+// checkSession checks that the stored session can still be used
+func (d *Doctor) checkSession(acc accountFile) {
+	session := &acc.account.Session
+
+	if session.AccessToken == "" && session.SteamLoginSecure == "" {
+		d.add(Finding{Severity: SeverityWarning, Check: "session", Subject: subject(acc),
+			Message: "no session stored, confirmations are unavailable until login"})
+		return
+	}
+	if session.AccessToken == "" {
+		d.add(Finding{Severity: SeverityInfo, Check: "session", Subject: subject(acc),
+			Message: "legacy session without access token, validity can't be checked"})
+		return
+	}
+	if !session.AccessTokenExpired() {
+		return
+	}
+
+	refresh := manifest.SessionData{AccessToken: session.RefreshToken}
+	if session.RefreshToken == "" || refresh.AccessTokenExpired() {
+		d.add(Finding{Severity: SeverityError, Check: "session", Subject: subject(acc),
+			Message: "access and refresh tokens have expired, login again"})
+		return
+	}
+
+	f := Finding{
+		Severity: SeverityWarning,
+		Check:    "session",
+		Subject:  subject(acc),
+		Message:  fmt.Sprintf("access token expired at %s", session.AccessTokenExpiry().Format(time.RFC3339)),
+	}
+	if !d.Offline && d.Client != nil {
+		f.FixDescription = "refresh the access token"
+		f.Fix = func() error {
+			if err := d.Client.RefreshAccessToken(session); err != nil {
+				return err
+			}
+			return d.writeAccount(acc)
+		}
+	}
+	d.add(f)
+}
+
+// checkClock compares the local clock with Steam's server time
+func (d *Doctor) checkClock() {
+	serverTime, err := d.Client.QueryTime()
+	if err != nil {
+		d.add(Finding{Severity: SeverityWarning, Check: "clock", Subject: "system clock", Message: err.Error()})
+		return
+	}
+
+	drift := time.Until(serverTime)
+	if drift < 0 {
+		drift = -drift
+	}
+
+	switch {
+	case drift >= driftError:
+		d.add(Finding{Severity: SeverityError, Check: "clock", Subject: "system clock",
+			Message: fmt.Sprintf("clock differs from Steam by %s, codes will be rejected; sync the system clock", drift.Round(time.Second))})
+	case drift >= driftWarning:
+		d.add(Finding{Severity: SeverityWarning, Check: "clock", Subject: "system clock",
+			Message: fmt.Sprintf("clock differs from Steam by %s; sync the system clock", drift.Round(time.Second))})
+	}
+}
+
+// removeEntry removes the entry with a missing file from the manifest
+func (d *Doctor) removeEntry(filename string) error {
+	for i, entry := range d.manifest.Entries {
+		if entry.Filename == filename {
+			d.manifest.Entries = append(d.manifest.Entries[:i], d.manifest.Entries[i+1:]...)
+			return d.saveManifest()
+		}
+	}
+	return nil
+}
+
+// addEntry adds an unlisted plain text account file to the manifest
+func (d *Doctor) addEntry(filename string) error {
+	data, err := os.ReadFile(filepath.Join(d.Path, filename))
+	if err != nil {
+		return err
+	}
+
+	account := &manifest.SteamGuardAccount{}
+	if err := json.Unmarshal(data, account); err != nil {
+		return fmt.Errorf("failed to parse account: %w", err)
+	}
+
+	d.manifest.Entries = append(d.manifest.Entries, manifest.ManifestEntry{
+		Filename: filename,
+		SteamID:  account.Session.SteamID,
+	})
+	return d.saveManifest()
+}
+
+// writeAccount writes an account file back, encrypting it if needed
+func (d *Doctor) writeAccount(acc accountFile) error {
+	entry := d.entry(acc.filename)
+	if entry == nil {
+		return fmt.Errorf("entry %s no longer exists", acc.filename)
+	}
+
+	data, err := json.MarshalIndent(acc.account, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize account: %w", err)
+	}
+
+	if entry.Encryption != nil {
+		passkey, err := d.getPasskey()
+		if err != nil {
+			return err
+		}
+		encrypted, iv, salt, err := crypto.Encrypt(data, passkey)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt: %w", err)
+		}
+		data = encrypted
+		entry.Encryption = &manifest.EncryptionParams{IV: iv, Salt: salt}
+	}
+
+	if err := os.WriteFile(filepath.Join(d.Path, entry.Filename), data, 0600); err != nil {
+		return fmt.Errorf("failed to write account file: %w", err)
+	}
+	if entry.Encryption != nil {
+		return d.saveManifest()
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/devhooly/steamguard-go/internal/crypto"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// Severity how serious a finding is
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// String returns the severity name
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// Finding one problem found by the audit
+type Finding struct {
+	Severity Severity
+	Check    string
+	Subject  string
+	Message  string
+	// FixDescription describes what Fix does, empty if there is no safe fix
+	FixDescription string
+	Fix            func() error
+}
+
+// Doctor audits a maFiles directory
+type Doctor struct {
+	Path    string
+	Client  *steamapi.Client
+	Offline bool
+	// Passkey returns the password of an encrypted manifest
+	Passkey func() (string, error)
+
+	manifest *manifest.Manifest
+	passkey  string
+	findings []Finding
+}
+
+// accountFile a parsed account file with its manifest entry
+type accountFile struct {
+	filename string
+	account  *manifest.SteamGuardAccount
+}
+
+// Run runs all checks and returns the findings, most severe first
+func (d *Doctor) Run() ([]Finding, error) {
+	d.findings = nil
+
+	d.checkPermissions()
+
+	if err := d.loadManifest(); err != nil {
+		d.add(Finding{Severity: SeverityError, Check: "manifest", Subject: "manifest.json", Message: err.Error()})
+		return d.sorted(), nil
+	}
+
+	d.checkEntries()
+	accounts := d.loadAccounts()
+	for _, acc := range accounts {
+		d.checkSecrets(acc)
+		d.checkIdentity(acc)
+		d.checkSession(acc)
+	}
+
+	if !d.Offline && d.Client != nil {
+		d.checkClock()
+	}
+
+	return d.sorted(), nil
+}
+
+// add records a finding
+func (d *Doctor) add(f Finding) {
+	d.findings = append(d.findings, f)
+}
+
+// sorted returns findings ordered by severity
+func (d *Doctor) sorted() []Finding {
+	findings := append([]Finding(nil), d.findings...)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity > findings[j].Severity
+	})
+	return findings
+}
+
+// manifestPath returns the path of manifest.json
+func (d *Doctor) manifestPath() string {
+	return filepath.Join(d.Path, "manifest.json")
+}
+
+// loadManifest reads and parses manifest.json
+func (d *Doctor) loadManifest() error {
+	data, err := os.ReadFile(d.manifestPath())
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	d.manifest = &manifest.Manifest{}
+	if err := json.Unmarshal(data, d.manifest); err != nil {
+		return fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return nil
+}
+
+// saveManifest writes manifest.json in the format used by the manager
+func (d *Doctor) saveManifest() error {
+	data, err := json.MarshalIndent(d.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize manifest: %w", err)
+	}
+	if err := os.WriteFile(d.manifestPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// loadAccounts reads, decrypts and parses every account file of the manifest
+func (d *Doctor) loadAccounts() []accountFile {
+	var accounts []accountFile
+
+	for _, entry := range d.manifest.Entries {
+		data, err := os.ReadFile(filepath.Join(d.Path, entry.Filename))
+		if err != nil {
+			// Missing files are reported by checkEntries
+			continue
+		}
+
+		looksJSON := json.Valid(data)
+		switch {
+		case d.manifest.Encrypted && entry.Encryption == nil:
+			d.add(Finding{Severity: SeverityError, Check: "encryption", Subject: entry.Filename,
+				Message: "manifest is encrypted but the entry has no IV/salt"})
+		case !d.manifest.Encrypted && entry.Encryption != nil:
+			d.add(Finding{Severity: SeverityError, Check: "encryption", Subject: entry.Filename,
+				Message: "manifest is not encrypted but the entry has IV/salt"})
+		}
+
+		if entry.Encryption != nil && !looksJSON {
+			passkey, err := d.getPasskey()
+			if err != nil {
+				d.add(Finding{Severity: SeverityError, Check: "encryption", Subject: entry.Filename, Message: err.Error()})
+				continue
+			}
+			decrypted, err := crypto.Decrypt(data, passkey, entry.Encryption.IV, entry.Encryption.Salt)
+			if err != nil {
+				d.add(Finding{Severity: SeverityError, Check: "encryption", Subject: entry.Filename,
+					Message: fmt.Sprintf("failed to decrypt: %v (wrong password or corrupted file)", err)})
+				continue
+			}
+			data = decrypted
+		} else if entry.Encryption != nil && looksJSON {
+			d.add(Finding{Severity: SeverityError, Check: "encryption", Subject: entry.Filename,
+				Message: "entry has encryption parameters but the file is stored in plain text"})
+		} else if !looksJSON {
+			d.add(Finding{Severity: SeverityError, Check: "encryption", Subject: entry.Filename,
+				Message: "file is not valid JSON and has no encryption parameters"})
+			continue
+		}
+
+		account := &manifest.SteamGuardAccount{}
+		if err := json.Unmarshal(data, account); err != nil {
+			d.add(Finding{Severity: SeverityError, Check: "account", Subject: entry.Filename,
+				Message: fmt.Sprintf("failed to parse account: %v", err)})
+			continue
+		}
+
+		accounts = append(accounts, accountFile{filename: entry.Filename, account: account})
+	}
+
+	return accounts
+}
+
+// getPasskey asks for the manifest password once
+func (d *Doctor) getPasskey() (string, error) {
+	if d.passkey != "" {
+		return d.passkey, nil
+	}
+	if d.Passkey == nil {
+		return "", fmt.Errorf("manifest is encrypted and no password was given")
+	}
+
+	passkey, err := d.Passkey()
+	if err != nil {
+		return "", err
+	}
+	d.passkey = passkey
+	return passkey, nil
+}
+
+// subject returns a readable name of an account for findings
+func subject(acc accountFile) string {
+	if acc.account.AccountName != "" {
+		return acc.account.AccountName
+	}
+	return acc.filename
+}
+
+// entry finds the manifest entry of a file. Fixes look entries up by
+// filename because earlier fixes may remove entries.
+func (d *Doctor) entry(filename string) *manifest.ManifestEntry {
+	for i := range d.manifest.Entries {
+		if d.manifest.Entries[i].Filename == filename {
+			return &d.manifest.Entries[i]
+		}
+	}
+	return nil
+}
+
+// isMaFile checks if a directory entry is an account file
+func isMaFile(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".mafile")
+}
@@ -0,0 +1,289 @@
+package enroll
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/mailcode"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// BatchState checkpoint of a batch enrollment run
+type BatchState struct {
+	Accounts map[string]*AccountState `json:"accounts"`
+}
+
+// AccountState enrollment progress of one batch account
+type AccountState struct {
+	Stage          Stage     `json:"stage"`
+	SteamID        string    `json:"steamid,omitempty"`
+	RevocationCode string    `json:"revocation_code,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// BatchEvent what RunBatch reports about an account
+type BatchEvent int
+
+const (
+	// BatchSkipped the account is already enrolled
+	BatchSkipped BatchEvent = iota
+	// BatchStarted enrollment or finalization of the account starts
+	BatchStarted
+	// BatchFinished the account is done, or failed with the state's Error
+	BatchFinished
+)
+
+// ReadCSV reads enrollment parameters from a CSV file with a header row.
+// Recognized columns: username, password, email, imap_host, imap_port,
+// imap_user, imap_password, phone_number, phone_country.
+func ReadCSV(path string) ([]Params, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("CSV has no username column")
+	}
+	if _, ok := columns["password"]; !ok {
+		return nil, fmt.Errorf("CSV has no password column")
+	}
+
+	var params []Params
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		p := Params{
+			Username:     field("username"),
+			Password:     field("password"),
+			Email:        field("email"),
+			PhoneNumber:  field("phone_number"),
+			PhoneCountry: field("phone_country"),
+		}
+		if p.Username == "" {
+			continue
+		}
+
+		if host := field("imap_host"); host != "" {
+			imap := &mailcode.Config{
+				Host:     host,
+				Username: field("imap_user"),
+				Password: field("imap_password"),
+			}
+			if imap.Username == "" {
+				imap.Username = p.Email
+			}
+			if port := field("imap_port"); port != "" {
+				if imap.Port, err = strconv.Atoi(port); err != nil {
+					return nil, fmt.Errorf("invalid imap_port on CSV line %d: %w", line, err)
+				}
+			}
+			p.IMAP = imap
+		}
+
+		params = append(params, p)
+	}
+
+	return params, nil
+}
+
+// LoadState loads the checkpoint file (an empty state if it doesn't exist)
+func LoadState(path string) (*BatchState, error) {
+	state := &BatchState{Accounts: make(map[string]*AccountState)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	if state.Accounts == nil {
+		state.Accounts = make(map[string]*AccountState)
+	}
+
+	return state, nil
+}
+
+// Save writes the checkpoint file
+func (s *BatchState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize state: %w", err)
+	}
+
+	// The state holds revocation codes, keep it private
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	return nil
+}
+
+// RunBatch enrolls every account, checkpointing after each step.
+// Accounts already done are skipped, accounts interrupted after
+// AddAuthenticator are only finalized. The batch stops when a checkpoint
+// can't be written.
+func (e *Enroller) RunBatch(params []Params, state *BatchState, statePath string) error {
+	for _, p := range params {
+		st, ok := state.Accounts[p.Username]
+		if !ok {
+			st = &AccountState{Stage: StagePending}
+			state.Accounts[p.Username] = st
+		}
+		if st.Stage == StageDone {
+			e.batchProgress(BatchSkipped, p.Username, st)
+			continue
+		}
+
+		e.batchProgress(BatchStarted, p.Username, st)
+		err := e.runOne(p, st, state, statePath)
+
+		st.UpdatedAt = time.Now()
+		if err != nil {
+			st.Error = err.Error()
+			if st.Stage != StageAdded {
+				st.Stage = StageFailed
+			}
+		} else {
+			st.Error = ""
+		}
+		e.batchProgress(BatchFinished, p.Username, st)
+
+		if err := state.Save(statePath); err != nil {
+			return err
+		}
+		var checkpointErr *checkpointError
+		if errors.As(err, &checkpointErr) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkpointError a checkpoint of the batch state couldn't be written
+type checkpointError struct {
+	err error
+}
+
+func (e *checkpointError) Error() string {
+	return fmt.Sprintf("failed to checkpoint the batch: %v", e.err)
+}
+
+func (e *checkpointError) Unwrap() error {
+	return e.err
+}
+
+// batchProgress reports a batch account
+func (e *Enroller) batchProgress(event BatchEvent, username string, st *AccountState) {
+	if e.BatchProgress != nil {
+		e.BatchProgress(event, username, st)
+	}
+}
+
+// runOne enrolls or finalizes one batch account
+func (e *Enroller) runOne(p Params, st *AccountState, state *BatchState, statePath string) error {
+	progress := e.Progress
+	defer func() { e.Progress = progress }()
+
+	e.Progress = func(stage Stage, account *manifest.SteamGuardAccount) error {
+		st.Stage = stage
+		st.SteamID = account.Session.SteamID
+		st.RevocationCode = account.RevocationCode
+		st.UpdatedAt = time.Now()
+		// Checkpoint right away: after AddAuthenticator the revocation code must survive a crash
+		if err := state.Save(statePath); err != nil {
+			return &checkpointError{err: err}
+		}
+		if progress != nil {
+			return progress(stage, account)
+		}
+		return nil
+	}
+
+	if st.Stage == StageAdded {
+		account, err := e.Manager.GetAccount(p.Username)
+		if err != nil {
+			return fmt.Errorf("saved account not found: %w", err)
+		}
+		// Accounts without a phone get the activation code by email
+		activation := steamapi.ActivationSMS
+		if p.PhoneNumber == "" {
+			activation = steamapi.ActivationEmail
+		}
+		return e.Finalize(account, activation, p)
+	}
+
+	account, err := e.Enroll(p)
+	if account != nil {
+		st.SteamID = account.Session.SteamID
+		st.RevocationCode = account.RevocationCode
+	}
+	return err
+}
+
+// WriteReport writes a summary of the batch with every revocation code
+func WriteReport(path string, params []Params, state *BatchState) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer file.Close()
+
+	w := tabwriter.NewWriter(file, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ACCOUNT\tSTEAMID\tSTATUS\tREVOCATION CODE\tERROR\n")
+	for _, p := range params {
+		st, ok := state.Accounts[p.Username]
+		if !ok {
+			st = &AccountState{Stage: StagePending}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.Username, st.SteamID, st.Stage, st.RevocationCode, st.Error)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
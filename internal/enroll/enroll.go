@@ -0,0 +1,187 @@
+package enroll
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/mailcode"
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+	"github.com/devhooly/steamguard-go/internal/steamguard"
+)
+
+// mailTimeout how long to wait for a Steam email to arrive
+const mailTimeout = 5 * time.Minute
+
+// Stage enrollment progress of an account
+type Stage string
+
+const (
+	StagePending Stage = "pending"
+	StageAdded   Stage = "added"
+	StageDone    Stage = "done"
+	StageFailed  Stage = "failed"
+)
+
+// Params account data needed for enrollment
+type Params struct {
+	Username     string
+	Password     string
+	Email        string
+	IMAP         *mailcode.Config
+	PhoneNumber  string
+	PhoneCountry string
+}
+
+// PromptFunc asks the user for a value
+type PromptFunc func(label string) (string, error)
+
+// Enroller adds authenticators to accounts and stores them in the manifest
+type Enroller struct {
+	Client  *steamapi.Client
+	Manager *manifest.Manager
+	Prompt  PromptFunc
+	// Progress is told about every stage reached; an error stops the enrollment
+	Progress func(stage Stage, account *manifest.SteamGuardAccount) error
+	// BatchProgress reports the accounts of RunBatch
+	BatchProgress func(event BatchEvent, username string, st *AccountState)
+}
+
+// Enroll logs in, adds an authenticator, saves the maFile and finalizes it
+func (e *Enroller) Enroll(p Params) (*manifest.SteamGuardAccount, error) {
+	session, err := e.login(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remember the newest Steam email so the activation email can be told apart
+	mark, err := mailMark(p)
+	if err != nil {
+		return nil, err
+	}
+
+	account, activation, err := e.addAuthenticator(session, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save before finalizing so the revocation code is never lost. The
+	// authenticator is live on Steam now, so the account is returned with
+	// its revocation code even when saving fails.
+	if err := e.Manager.AddAccount(account); err != nil {
+		return account, fmt.Errorf("failed to save account: %w", err)
+	}
+	if err := e.progress(StageAdded, account); err != nil {
+		return account, err
+	}
+
+	if err := e.finalize(account, activation, p, mark); err != nil {
+		return account, err
+	}
+
+	return account, nil
+}
+
+// Finalize completes enrollment of an account saved by an interrupted Enroll.
+// With IMAP configured the newest Steam email is expected to hold the code,
+// pass activation 0 when it's unknown where the code was sent.
+func (e *Enroller) Finalize(account *manifest.SteamGuardAccount, activation steamapi.ActivationType, p Params) error {
+	return e.finalize(account, activation, p, 0)
+}
+
+// login logs in, reading the email code from IMAP when configured
+func (e *Enroller) login(p Params) (*manifest.SessionData, error) {
+	mark, err := mailMark(p)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := func(kind steamapi.GuardCodeType) (string, error) {
+		if kind == steamapi.GuardCodeEmail && p.IMAP != nil {
+			return p.IMAP.WaitForCode(mark, mailTimeout)
+		}
+		if kind == steamapi.GuardCodeEmail {
+			return e.Prompt(fmt.Sprintf("Enter Steam Guard code sent to email of %s: ", p.Username))
+		}
+		return e.Prompt(fmt.Sprintf("Enter Steam Guard code for %s: ", p.Username))
+	}
+
+	session, err := e.Client.Login(p.Username, p.Password, codes)
+	if err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	return session, nil
+}
+
+// addAuthenticator adds the authenticator, attaching the phone number if Steam requires it
+func (e *Enroller) addAuthenticator(session *manifest.SessionData, p Params) (*manifest.SteamGuardAccount, steamapi.ActivationType, error) {
+	deviceID := steamguard.GenerateDeviceID(session.SteamID)
+
+	account, activation, err := e.Client.AddAuthenticator(session, deviceID)
+	if err != steamapi.ErrPhoneRequired || p.PhoneNumber == "" {
+		return account, activation, err
+	}
+
+	email, err := e.Client.SetAccountPhoneNumber(session, p.PhoneNumber, p.PhoneCountry)
+	if err != nil {
+		return nil, 0, err
+	}
+	fmt.Printf("Confirm the phone number of %s using the link sent to %s\n", p.Username, email)
+	if err := e.Client.WaitForPhoneEmailConfirmation(session, mailTimeout); err != nil {
+		return nil, 0, err
+	}
+	if err := e.Client.SendPhoneVerificationCode(session); err != nil {
+		return nil, 0, err
+	}
+
+	return e.Client.AddAuthenticator(session, deviceID)
+}
+
+// finalize finalizes the authenticator and saves the fully enrolled account
+func (e *Enroller) finalize(account *manifest.SteamGuardAccount, activation steamapi.ActivationType, p Params, mark uint32) error {
+	code, err := e.activationCode(account, activation, p, mark)
+	if err != nil {
+		return err
+	}
+
+	if err := e.Client.FinalizeAddAuthenticator(account, code); err != nil {
+		return err
+	}
+
+	if err := e.Manager.UpdateAccount(account); err != nil {
+		return fmt.Errorf("failed to save account: %w", err)
+	}
+	return e.progress(StageDone, account)
+}
+
+// activationCode reads the activation code from IMAP or asks the user
+func (e *Enroller) activationCode(account *manifest.SteamGuardAccount, activation steamapi.ActivationType, p Params, mark uint32) (string, error) {
+	if activation == steamapi.ActivationEmail && p.IMAP != nil {
+		return p.IMAP.WaitForCode(mark, mailTimeout)
+	}
+
+	switch activation {
+	case steamapi.ActivationEmail:
+		return e.Prompt(fmt.Sprintf("Enter activation code sent to email of %s: ", account.AccountName))
+	case steamapi.ActivationSMS:
+		return e.Prompt(fmt.Sprintf("Enter SMS code for %s: ", account.AccountName))
+	default:
+		return e.Prompt(fmt.Sprintf("Enter activation code (SMS or email) for %s: ", account.AccountName))
+	}
+}
+
+// progress reports an enrollment stage
+func (e *Enroller) progress(stage Stage, account *manifest.SteamGuardAccount) error {
+	if e.Progress == nil {
+		return nil
+	}
+	return e.Progress(stage, account)
+}
+
+// mailMark returns the IMAP mark when a mailbox is configured
+func mailMark(p Params) (uint32, error) {
+	if p.IMAP == nil {
+		return 0, nil
+	}
+	return p.IMAP.Mark()
+}
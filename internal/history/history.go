@@ -0,0 +1,308 @@
+package history
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// FileName name of the history log in the maFiles directory
+const FileName = "history.jsonl"
+
+// Decision sources
+const (
+	SourceManual = "manual"
+	SourceRule   = "rule"
+	SourceAPI    = "api"
+)
+
+// Results
+const (
+	ResultOK     = "ok"
+	ResultFailed = "failed"
+)
+
+// lockTimeout how long to wait for another process appending to the log
+const lockTimeout = 10 * time.Second
+
+// staleLockAge lock files older than this are left over from a crashed process
+const staleLockAge = time.Minute
+
+// Entry one accept or reject performed by the tool
+type Entry struct {
+	Seq            int       `json:"seq"`
+	Time           time.Time `json:"time"`
+	Account        string    `json:"account"`
+	ConfirmationID string    `json:"confirmation_id"`
+	CreatorID      string    `json:"creator_id,omitempty"`
+	Type           string    `json:"type"`
+	Headline       string    `json:"headline"`
+	Action         string    `json:"action"`
+	Source         string    `json:"source"`
+	// Detail what made the decision, e.g. the matching rule
+	Detail   string `json:"detail,omitempty"`
+	Result   string `json:"result"`
+	Error    string `json:"error,omitempty"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// NewEntry builds an entry for a confirmation. err is the outcome of the action.
+func NewEntry(account string, conf *steamapi.Confirmation, action, source, detail string, err error) Entry {
+	entry := Entry{
+		Time:           time.Now().UTC(),
+		Account:        account,
+		ConfirmationID: conf.ID,
+		CreatorID:      conf.CreatorID,
+		Type:           conf.Type.String(),
+		Headline:       conf.Description(),
+		Action:         action,
+		Source:         source,
+		Detail:         detail,
+		Result:         ResultOK,
+	}
+	if err != nil {
+		entry.Result = ResultFailed
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// computeHash hashes the entry with its hash field cleared
+func (e Entry) computeHash() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Log append-only, hash-chained history log
+type Log struct {
+	Path string
+}
+
+// Open returns the log at path; the file is created on first append
+func Open(path string) *Log {
+	return &Log{Path: path}
+}
+
+// Append chains and appends entries to the log
+func (l *Log) Append(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	last, err := l.last()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, entry := range entries {
+		entry.Seq = last.Seq + 1
+		entry.PrevHash = last.Hash
+		entry.Hash = entry.computeHash()
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode history entry: %w", err)
+		}
+		writer.Write(data)
+		writer.WriteByte('\n')
+		last = entry
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return file.Sync()
+}
+
+// last returns the last entry, or an empty entry when the log is empty
+func (l *Log) last() (Entry, error) {
+	var last Entry
+	err := l.scan(func(entry Entry) error {
+		last = entry
+		return nil
+	})
+	return last, err
+}
+
+// scan calls fn for every entry in order
+func (l *Log) scan(fn func(Entry) error) error {
+	file, err := os.Open(l.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("history line %d is corrupt: %w", line, err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries passing the filter
+func (l *Log) Query(filter Filter) ([]Entry, error) {
+	var entries []Entry
+	err := l.scan(func(entry Entry) error {
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Head the sequence number and hash of the last entry. The chain alone
+// can't tell a rewritten or truncated log from an intact one, so the head
+// printed by 'history verify' should be recorded outside the maFiles
+// directory and passed back to Verify.
+type Head struct {
+	Seq  int
+	Hash string
+}
+
+// String formats the head as seq:hash
+func (h Head) String() string {
+	return fmt.Sprintf("%d:%s", h.Seq, h.Hash)
+}
+
+// ParseHead parses a head formatted by String
+func ParseHead(text string) (Head, error) {
+	seq, hash, _ := strings.Cut(strings.TrimSpace(text), ":")
+	n, err := strconv.Atoi(seq)
+	if err != nil || n < 1 || len(hash) != sha256.Size*2 {
+		return Head{}, fmt.Errorf("invalid history head %q, expected <seq>:<hash>", text)
+	}
+	return Head{Seq: n, Hash: hash}, nil
+}
+
+// Verify checks the hash chain and returns its head. With an anchor, a head
+// recorded earlier, the log must still contain that entry unchanged.
+// The error describes the first entry that was edited, removed or reordered.
+func (l *Log) Verify(anchor *Head) (Head, error) {
+	var prev Entry
+	anchored := false
+	err := l.scan(func(entry Entry) error {
+		if entry.Seq != prev.Seq+1 {
+			return fmt.Errorf("entry %d follows entry %d, entries were removed or reordered", entry.Seq, prev.Seq)
+		}
+		if entry.PrevHash != prev.Hash {
+			return fmt.Errorf("entry %d doesn't chain to entry %d", entry.Seq, prev.Seq)
+		}
+		if entry.Hash != entry.computeHash() {
+			return fmt.Errorf("entry %d was modified", entry.Seq)
+		}
+		if anchor != nil && entry.Seq == anchor.Seq {
+			if entry.Hash != anchor.Hash {
+				return fmt.Errorf("entry %d doesn't match the expected head, the log was rewritten", entry.Seq)
+			}
+			anchored = true
+		}
+		prev = entry
+		return nil
+	})
+	head := Head{Seq: prev.Seq, Hash: prev.Hash}
+	if err == nil && anchor != nil && !anchored {
+		err = fmt.Errorf("the log ends at entry %d before the expected head %d, entries were removed", head.Seq, anchor.Seq)
+	}
+	return head, err
+}
+
+// lock creates the lock file, waiting for other processes appending to the log
+func (l *Log) lock() (func(), error) {
+	lockPath := l.Path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock history: %w", err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("history is locked by another process (%s)", lockPath)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Filter selects entries. Empty fields match everything.
+type Filter struct {
+	Accounts []string
+	Types    []string
+	Sources  []string
+	Since    time.Time
+	Until    time.Time
+}
+
+// Matches checks if an entry passes the filter
+func (f Filter) Matches(entry Entry) bool {
+	if len(f.Accounts) > 0 && !slices.ContainsFunc(f.Accounts, func(item string) bool { return strings.EqualFold(item, entry.Account) }) {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.ContainsFunc(f.Types, func(item string) bool { return strings.EqualFold(item, entry.Type) }) {
+		return false
+	}
+	if len(f.Sources) > 0 && !slices.ContainsFunc(f.Sources, func(item string) bool { return strings.EqualFold(item, entry.Source) }) {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
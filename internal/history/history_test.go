@@ -0,0 +1,195 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// testLog returns a log with entries for the confirmation IDs
+func testLog(t *testing.T, ids ...string) *Log {
+	t.Helper()
+	log := Open(filepath.Join(t.TempDir(), FileName))
+	for _, id := range ids {
+		conf := &steamapi.Confirmation{ID: id, Type: steamapi.ConfTypeTrade, Headline: "Trade " + id}
+		if err := log.Append(NewEntry("bot1", conf, "accept", SourceManual, "", nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return log
+}
+
+// readLines returns the lines of the log file
+func readLines(t *testing.T, log *Log) []string {
+	t.Helper()
+	data, err := os.ReadFile(log.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// writeLines replaces the log file
+func writeLines(t *testing.T, log *Log, lines []string) {
+	t.Helper()
+	if err := os.WriteFile(log.Path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppendChains(t *testing.T) {
+	log := testLog(t, "1", "2")
+	conf := &steamapi.Confirmation{ID: "3", Type: steamapi.ConfTypeMarketListing}
+	if err := log.Append(NewEntry("bot2", conf, "reject", SourceRule, "rule 1", errors.New("boom"))); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := log.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("%d entries, want 3", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Seq != i+1 {
+			t.Errorf("entry %d has seq %d", i, entry.Seq)
+		}
+		if i > 0 && entry.PrevHash != entries[i-1].Hash {
+			t.Errorf("entry %d doesn't chain to the previous one", entry.Seq)
+		}
+		if entry.Hash != entry.computeHash() {
+			t.Errorf("entry %d has a wrong hash", entry.Seq)
+		}
+	}
+	if entries[0].PrevHash != "" {
+		t.Errorf("first entry chains to %q", entries[0].PrevHash)
+	}
+	if last := entries[2]; last.Result != ResultFailed || last.Error != "boom" || last.Source != SourceRule {
+		t.Errorf("last entry = %+v", last)
+	}
+
+	head, err := log.Verify(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Seq != 3 || head.Hash != entries[2].Hash {
+		t.Errorf("head = %s, want 3:%s", head, entries[2].Hash)
+	}
+}
+
+func TestVerifyEmpty(t *testing.T) {
+	head, err := Open(filepath.Join(t.TempDir(), FileName)).Verify(nil)
+	if err != nil || head.Seq != 0 {
+		t.Errorf("Verify of a missing log = %s, %v; want 0, nil", head, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		want   string
+	}{
+		{"edited entry", func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], `"action":"accept"`, `"action":"reject"`, 1)
+			return lines
+		}, "entry 2 was modified"},
+		{"removed entry", func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		}, "entries were removed or reordered"},
+		{"reordered entries", func(lines []string) []string {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		}, "entries were removed or reordered"},
+		{"removed first entry", func(lines []string) []string {
+			return lines[1:]
+		}, "entry 2 follows entry 0"},
+		{"corrupt line", func(lines []string) []string {
+			lines[2] = "{"
+			return lines
+		}, "line 3 is corrupt"},
+	}
+	for _, tt := range tests {
+		log := testLog(t, "1", "2", "3", "4")
+		writeLines(t, log, tt.tamper(readLines(t, log)))
+
+		_, err := log.Verify(nil)
+		if err == nil {
+			t.Errorf("%s: Verify passed", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q doesn't contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestVerifyAnchor(t *testing.T) {
+	log := testLog(t, "1", "2", "3")
+	head, err := log.Verify(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The log may grow past the anchor
+	conf := &steamapi.Confirmation{ID: "4", Type: steamapi.ConfTypeTrade}
+	if err := log.Append(NewEntry("bot1", conf, "accept", SourceManual, "", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := log.Verify(&head); err != nil {
+		t.Errorf("grown log: %v", err)
+	}
+
+	// Dropping the last entries keeps the chain intact, the anchor catches it
+	lines := readLines(t, log)
+	writeLines(t, log, lines[:2])
+	if _, err := log.Verify(nil); err != nil {
+		t.Errorf("truncated log without anchor: %v", err)
+	}
+	if _, err := log.Verify(&head); err == nil || !strings.Contains(err.Error(), "entries were removed") {
+		t.Errorf("truncated log: error %v, want removed entries", err)
+	}
+
+	// Rewriting an entry and recomputing every hash too
+	rewritten := testLog(t, "1", "2", "x")
+	writeLines(t, log, readLines(t, rewritten))
+	if _, err := log.Verify(nil); err != nil {
+		t.Errorf("rewritten log without anchor: %v", err)
+	}
+	if _, err := log.Verify(&head); err == nil || !strings.Contains(err.Error(), "rewritten") {
+		t.Errorf("rewritten log: error %v, want rewritten", err)
+	}
+}
+
+func TestParseHead(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	tests := []struct {
+		text    string
+		want    Head
+		wantErr bool
+	}{
+		{"42:" + hash, Head{Seq: 42, Hash: hash}, false},
+		{" 1:" + hash + "\n", Head{Seq: 1, Hash: hash}, false},
+		{"0:" + hash, Head{}, true},
+		{"42", Head{}, true},
+		{"42:abc", Head{}, true},
+		{"x:" + hash, Head{}, true},
+		{"", Head{}, true},
+	}
+	for _, tt := range tests {
+		head, err := ParseHead(tt.text)
+		if (err != nil) != tt.wantErr || head != tt.want {
+			t.Errorf("ParseHead(%q) = %s, %v; want %s, error %v", tt.text, head, err, tt.want, tt.wantErr)
+		}
+	}
+
+	head := Head{Seq: 7, Hash: hash}
+	if parsed, err := ParseHead(head.String()); err != nil || parsed != head {
+		t.Errorf("ParseHead(String()) = %s, %v", parsed, err)
+	}
+}
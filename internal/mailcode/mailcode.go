@@ -0,0 +1,241 @@
+package mailcode
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// steamSender is the address Steam sends Steam Guard and activation codes from
+const steamSender = "noreply@steampowered.com"
+
+// codePattern matches a standalone Steam Guard or activation code line
+var codePattern = regexp.MustCompile(`(?m)^\s*([A-Z0-9]{5})\s*$`)
+
+// Config IMAP mailbox settings for reading Steam codes
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+// Mark returns the highest UID of Steam messages currently in the inbox.
+// Codes are only read from messages that arrive after the mark.
+func (c Config) Mark() (uint32, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.close()
+
+	uids, err := conn.searchSteam()
+	if err != nil {
+		return 0, err
+	}
+
+	var mark uint32
+	for _, uid := range uids {
+		if uid > mark {
+			mark = uid
+		}
+	}
+	return mark, nil
+}
+
+// WaitForCode polls the inbox until a Steam message newer than mark arrives
+// and returns the code it contains
+func (c Config) WaitForCode(mark uint32, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		code, err := c.findCode(mark)
+		if err != nil {
+			return "", err
+		}
+		if code != "" {
+			return code, nil
+		}
+		time.Sleep(10 * time.Second)
+	}
+
+	return "", fmt.Errorf("timed out waiting for Steam email")
+}
+
+// findCode returns the code from the newest Steam message after mark
+func (c Config) findCode(mark uint32) (string, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return "", err
+	}
+	defer conn.close()
+
+	uids, err := conn.searchSteam()
+	if err != nil {
+		return "", err
+	}
+
+	var newest uint32
+	for _, uid := range uids {
+		if uid > mark && uid > newest {
+			newest = uid
+		}
+	}
+	if newest == 0 {
+		return "", nil
+	}
+
+	body, err := conn.fetchText(newest)
+	if err != nil {
+		return "", err
+	}
+
+	return extractCode(body), nil
+}
+
+// extractCode finds the code in a message body
+func extractCode(body string) string {
+	if decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body))); err == nil {
+		body = string(decoded)
+	}
+	body = regexp.MustCompile(`<[^>]*>`).ReplaceAllString(body, "\n")
+
+	match := codePattern.FindStringSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// imapConn minimal IMAP client connection
+type imapConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	tag    int
+}
+
+// dial connects and logs in to the mailbox
+func (c Config) dial() (*imapConn, error) {
+	port := c.Port
+	if port == 0 {
+		port = 993
+	}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(port))
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{ServerName: c.Host})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	ic := &imapConn{conn: conn, reader: bufio.NewReader(conn)}
+	if _, err := ic.reader.ReadString('\n'); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read IMAP greeting: %w", err)
+	}
+
+	if _, err := ic.command("LOGIN %s %s", quote(c.Username), quote(c.Password)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("IMAP login failed: %w", err)
+	}
+	if _, err := ic.command("SELECT INBOX"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to select inbox: %w", err)
+	}
+
+	return ic, nil
+}
+
+// close logs out and closes the connection
+func (ic *imapConn) close() {
+	ic.command("LOGOUT")
+	ic.conn.Close()
+}
+
+// searchSteam returns UIDs of messages sent by Steam
+func (ic *imapConn) searchSteam() ([]uint32, error) {
+	lines, err := ic.command("UID SEARCH FROM %s", quote(steamSender))
+	if err != nil {
+		return nil, fmt.Errorf("IMAP search failed: %w", err)
+	}
+
+	var uids []uint32
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "* SEARCH") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(line, "* SEARCH")) {
+			uid, err := strconv.ParseUint(field, 10, 32)
+			if err == nil {
+				uids = append(uids, uint32(uid))
+			}
+		}
+	}
+	return uids, nil
+}
+
+// fetchText returns the text body of a message
+func (ic *imapConn) fetchText(uid uint32) (string, error) {
+	lines, err := ic.command("UID FETCH %d BODY.PEEK[TEXT]", uid)
+	if err != nil {
+		return "", fmt.Errorf("IMAP fetch failed: %w", err)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// command sends a tagged command and returns untagged response lines
+// (literals are inlined) until the tagged completion
+func (ic *imapConn) command(format string, args ...interface{}) ([]string, error) {
+	ic.tag++
+	tag := fmt.Sprintf("a%d", ic.tag)
+
+	ic.conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if _, err := fmt.Fprintf(ic.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for {
+		line, err := ic.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return nil, fmt.Errorf("%s", status)
+			}
+			return lines, nil
+		}
+
+		// Literal: {n} at the end of the line followed by n bytes
+		if strings.HasSuffix(line, "}") {
+			if i := strings.LastIndex(line, "{"); i >= 0 {
+				if n, err := strconv.Atoi(line[i+1 : len(line)-1]); err == nil {
+					literal := make([]byte, n)
+					if _, err := io.ReadFull(ic.reader, literal); err != nil {
+						return nil, err
+					}
+					lines = append(lines, line[:i], string(literal))
+					continue
+				}
+			}
+		}
+
+		lines = append(lines, line)
+	}
+}
+
+// quote quotes an IMAP string argument
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -1,11 +1,19 @@
 package manifest
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/devhooly/steamguard-go/internal/steamguard"
 )
 
+// ErrNotFullyEnrolled is returned for accounts whose setup was interrupted before finalization
+var ErrNotFullyEnrolled = errors.New("authenticator setup was not finished, run 'steamguard setup --resume'")
+
 // SteamGuardAccount represents a Steam Guard account (SDA format)
 type SteamGuardAccount struct {
 	AccountName        string        `json:"account_name"`
@@ -21,6 +29,9 @@ type SteamGuardAccount struct {
 	DeviceID           string        `json:"device_id"`
 	FullyEnrolled      bool          `json:"fully_enrolled"`
 	Session            SessionData   `json:"Session"`
+	// WebAPIKey Steam Web API key, used when the session has no access token.
+	// Kept in the maFile so it's encrypted with the secrets.
+	WebAPIKey          string        `json:"web_api_key,omitempty"`
 }
 
 // SessionData represents Steam session data
@@ -31,6 +42,8 @@ type SessionData struct {
 	WebCookie         string `json:"WebCookie"`
 	OAuthToken        string `json:"OAuthToken"`
 	SteamID           string `json:"SteamID"`
+	AccessToken       string `json:"AccessToken,omitempty"`
+	RefreshToken      string `json:"RefreshToken,omitempty"`
 }
 
 // GenerateCode generates the current Steam Guard code
@@ -68,3 +81,40 @@ func (a *SteamGuardAccount) GetDeviceID() string {
 	}
 	return a.DeviceID
 }
+
+// CheckEnrolled returns an error if the authenticator was never finalized.
+// Such accounts are not active on Steam and must not be used for confirmations.
+func (a *SteamGuardAccount) CheckEnrolled() error {
+	if !a.FullyEnrolled {
+		return fmt.Errorf("account %s: %w", a.AccountName, ErrNotFullyEnrolled)
+	}
+	return nil
+}
+
+// AccessTokenExpiry returns the expiry time of the access token (zero if unknown)
+func (s *SessionData) AccessTokenExpiry() time.Time {
+	parts := strings.Split(s.AccessToken, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Exp, 0)
+}
+
+// AccessTokenExpired checks if the access token is missing or expired
+func (s *SessionData) AccessTokenExpired() bool {
+	expiry := s.AccessTokenExpiry()
+	return expiry.IsZero() || time.Now().After(expiry)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/devhooly/steamguard-go/internal/crypto"
 )
@@ -33,9 +34,33 @@ type EncryptionParams struct {
 	Salt string `json:"salt"`
 }
 
-// AutoConfirmRule rule for automatic confirmation
+// AutoConfirmRule rule for automatic confirmation. Action is accept, reject
+// or ignore; a rule matches when all of its non-empty conditions match.
 type AutoConfirmRule struct {
+	Name   string `json:"name,omitempty"`
 	Action string `json:"action"`
+	// Type and Types confirmation types (trade, market, ...)
+	Type  string   `json:"type,omitempty"`
+	Types []string `json:"types,omitempty"`
+	// Creators trade offer or listing IDs
+	Creators []string `json:"creators,omitempty"`
+	// Match regular expression matched against headline and summary
+	Match string `json:"match,omitempty"`
+	// AccountTags the account must have all tags, AccountGroups any group
+	AccountTags   []string `json:"account_tags,omitempty"`
+	AccountGroups []string `json:"account_groups,omitempty"`
+	// MinPrice and MaxPrice bounds of the price from the confirmation details
+	MinPrice *float64 `json:"min_price,omitempty"`
+	MaxPrice *float64 `json:"max_price,omitempty"`
+	// PartnerAllow the partner's SteamID must be listed, PartnerDeny must not be
+	PartnerAllow []string `json:"partner_allow,omitempty"`
+	PartnerDeny  []string `json:"partner_deny,omitempty"`
+	// MaxItemsGiven most items the trade may take from the account
+	MaxItemsGiven *int `json:"max_items_given,omitempty"`
+	// AllowHold lets the rule accept trades that would be held
+	AllowHold bool `json:"allow_hold,omitempty"`
+	// TimeOfDay local time range like "09:00-18:00", may wrap midnight
+	TimeOfDay string `json:"time_of_day,omitempty"`
 }
 
 // Manager manages the manifest and accounts
@@ -43,6 +68,8 @@ type Manager struct {
 	mu          sync.RWMutex
 	manifest    *Manifest
 	accounts    map[string]*SteamGuardAccount
+	order       []string
+	metadata    *Metadata
 	maFilesPath string
 	passkey     string
 }
@@ -52,6 +79,10 @@ func NewManager(maFilesPath string) (*Manager, error) {
 	mgr := &Manager{
 		maFilesPath: maFilesPath,
 		accounts:    make(map[string]*SteamGuardAccount),
+		metadata: &Metadata{
+			Accounts: make(map[string]*AccountMeta),
+			Aliases:  make(map[string]string),
+		},
 	}
 
 	// Create directory if it doesn't exist
@@ -98,13 +129,20 @@ func (m *Manager) Load() error {
 		return fmt.Errorf("failed to parse manifest: %w", err)
 	}
 
-	// Load all accounts
+	if err := m.loadMetadata(); err != nil {
+		return err
+	}
+
+	// Load all accounts, keeping the manifest entry order
+	m.accounts = make(map[string]*SteamGuardAccount)
+	m.order = nil
 	for _, entry := range m.manifest.Entries {
 		account, err := m.loadAccount(entry)
 		if err != nil {
 			return fmt.Errorf("failed to load account %s: %w", entry.SteamID, err)
 		}
 		m.accounts[account.AccountName] = account
+		m.order = append(m.order, account.AccountName)
 	}
 
 	return nil
@@ -167,12 +205,113 @@ func (m *Manager) AddAccount(account *SteamGuardAccount) error {
 	defer m.mu.Unlock()
 
 	filename := fmt.Sprintf("%s.maFile", account.AccountName)
+
+	encParams, err := m.writeAccountFile(account, filename)
+	if err != nil {
+		return err
+	}
+
+	// Add to manifest
+	entry := ManifestEntry{
+		Encryption: encParams,
+		Filename:   filename,
+		SteamID:    account.Session.SteamID,
+	}
+	m.manifest.Entries = append(m.manifest.Entries, entry)
+	m.accounts[account.AccountName] = account
+	m.order = append(m.order, account.AccountName)
+
+	// Save manifest
+	if err := m.saveUnlocked(); err != nil {
+		return err
+	}
+
+	meta, ok := m.metadata.Accounts[account.AccountName]
+	if !ok {
+		meta = &AccountMeta{}
+		m.metadata.Accounts[account.AccountName] = meta
+	}
+	now := time.Now()
+	meta.CreatedAt = &now
+	return m.saveMetadataUnlocked()
+}
+
+// UpdateAccount rewrites the maFile of an already added account
+func (m *Manager) UpdateAccount(account *SteamGuardAccount) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	idx := m.entryIndex(account)
+	if idx < 0 {
+		return fmt.Errorf("account %s not found", account.AccountName)
+	}
+
+	entry := &m.manifest.Entries[idx]
+	encParams, err := m.writeAccountFile(account, entry.Filename)
+	if err != nil {
+		return err
+	}
+	entry.Encryption = encParams
+	entry.SteamID = account.Session.SteamID
+	m.accounts[account.AccountName] = account
+
+	return m.saveUnlocked()
+}
+
+// RemoveAccount removes an account from the manifest and deletes its maFile
+func (m *Manager) RemoveAccount(account *SteamGuardAccount) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	idx := m.entryIndex(account)
+	if idx < 0 {
+		return fmt.Errorf("account %s not found", account.AccountName)
+	}
+
+	accountPath := filepath.Join(m.maFilesPath, m.manifest.Entries[idx].Filename)
+	if err := os.Remove(accountPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete account file: %w", err)
+	}
+
+	m.manifest.Entries = append(m.manifest.Entries[:idx], m.manifest.Entries[idx+1:]...)
+	delete(m.accounts, account.AccountName)
+	for i, name := range m.order {
+		if name == account.AccountName {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+
+	if err := m.saveUnlocked(); err != nil {
+		return err
+	}
+
+	m.forgetMetadataUnlocked(account.AccountName)
+	return m.saveMetadataUnlocked()
+}
+
+// entryIndex finds the manifest entry of an account by SteamID or filename
+func (m *Manager) entryIndex(account *SteamGuardAccount) int {
+	filename := fmt.Sprintf("%s.maFile", account.AccountName)
+	for i, entry := range m.manifest.Entries {
+		if account.Session.SteamID != "" && entry.SteamID == account.Session.SteamID {
+			return i
+		}
+		if entry.Filename == filename {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeAccountFile serializes, optionally encrypts and writes an account file
+func (m *Manager) writeAccountFile(account *SteamGuardAccount, filename string) (*EncryptionParams, error) {
 	accountPath := filepath.Join(m.maFilesPath, filename)
 
 	// Serialize account
 	data, err := json.MarshalIndent(account, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to serialize account: %w", err)
+		return nil, fmt.Errorf("failed to serialize account: %w", err)
 	}
 
 	// If encryption is needed
@@ -185,7 +324,7 @@ func (m *Manager) AddAccount(account *SteamGuardAccount) error {
 
 		encrypted, iv, salt, err := crypto.Encrypt(data, m.passkey)
 		if err != nil {
-			return fmt.Errorf("failed to encrypt: %w", err)
+			return nil, fmt.Errorf("failed to encrypt: %w", err)
 		}
 		data = encrypted
 		encParams = &EncryptionParams{
@@ -196,20 +335,10 @@ func (m *Manager) AddAccount(account *SteamGuardAccount) error {
 
 	// Write file
 	if err := os.WriteFile(accountPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write account file: %w", err)
-	}
-
-	// Add to manifest
-	entry := ManifestEntry{
-		Encryption: encParams,
-		Filename:   filename,
-		SteamID:    account.Session.SteamID,
+		return nil, fmt.Errorf("failed to write account file: %w", err)
 	}
-	m.manifest.Entries = append(m.manifest.Entries, entry)
-	m.accounts[account.AccountName] = account
 
-	// Save manifest
-	return m.saveUnlocked()
+	return encParams, nil
 }
 
 // saveUnlocked saves the manifest without locking (for internal use)
@@ -228,42 +357,45 @@ func (m *Manager) saveUnlocked() error {
 	return nil
 }
 
-// GetAccount returns an account by name (or the first one if name is not specified)
-func (m *Manager) GetAccount(username string) (*SteamGuardAccount, error) {
+// GetAllAccounts returns all accounts in manifest entry order
+func (m *Manager) GetAllAccounts() []*SteamGuardAccount {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if username == "" {
-		// Return the first account
-		if len(m.accounts) == 0 {
-			return nil, fmt.Errorf("no accounts")
-		}
-		for _, account := range m.accounts {
-			return account, nil
-		}
-	}
-
-	account, ok := m.accounts[username]
-	if !ok {
-		return nil, fmt.Errorf("account %s not found", username)
+	accounts := make([]*SteamGuardAccount, 0, len(m.order))
+	for _, name := range m.order {
+		accounts = append(accounts, m.accounts[name])
 	}
 
-	return account, nil
+	return accounts
 }
 
-// GetAllAccounts returns all accounts
-func (m *Manager) GetAllAccounts() []*SteamGuardAccount {
+// GetPartialAccounts returns accounts whose authenticator setup was not finalized
+func (m *Manager) GetPartialAccounts() []*SteamGuardAccount {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	accounts := make([]*SteamGuardAccount, 0, len(m.accounts))
-	for _, account := range m.accounts {
-		accounts = append(accounts, account)
+	var accounts []*SteamGuardAccount
+	for _, name := range m.order {
+		if account := m.accounts[name]; !account.FullyEnrolled {
+			accounts = append(accounts, account)
+		}
 	}
 
 	return accounts
 }
 
+// GetManifest returns a copy of the manifest settings
+func (m *Manager) GetManifest() Manifest {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	manifest := *m.manifest
+	manifest.Entries = append([]ManifestEntry(nil), m.manifest.Entries...)
+	manifest.AutoConfirm = append([]AutoConfirmRule(nil), m.manifest.AutoConfirm...)
+	return manifest
+}
+
 // IsEmpty checks if there are any accounts
 func (m *Manager) IsEmpty() bool {
 	m.mu.RLock()
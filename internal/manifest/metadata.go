@@ -0,0 +1,273 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// metadataFilename sidecar file with steamguard-only data.
+// SDA ignores unknown files, so the maFiles stay compatible.
+const metadataFilename = "steamguard.json"
+
+// Metadata represents the steamguard.json sidecar file
+type Metadata struct {
+	Accounts map[string]*AccountMeta `json:"accounts"`
+	// Default account used when none is selected
+	Default string `json:"default,omitempty"`
+	// Aliases maps user-defined names to account names
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// PriceFloors lowest price market listings of an item are accepted at,
+	// DefaultPriceFloor applies to items without their own floor
+	PriceFloors map[string]float64 `json:"price_floors,omitempty"`
+}
+
+// PriceFloorKey returns the key of an item's price floor; item names are
+// matched ignoring case
+func PriceFloorKey(item string) string {
+	return strings.ToLower(strings.TrimSpace(item))
+}
+
+// DefaultPriceFloor key of the price floor used for items without their own
+const DefaultPriceFloor = "*"
+
+// AccountMeta per-account data that the SDA format has no place for
+type AccountMeta struct {
+	Tags      []string   `json:"tags,omitempty"`
+	Groups    []string   `json:"groups,omitempty"`
+	Notes     string     `json:"notes,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// Selector selects accounts by metadata. An account matches if it has
+// every tag and belongs to at least one of the groups.
+type Selector struct {
+	Tags   []string
+	Groups []string
+}
+
+// IsEmpty checks if the selector selects all accounts
+func (s Selector) IsEmpty() bool {
+	return len(s.Tags) == 0 && len(s.Groups) == 0
+}
+
+// Matches checks if account metadata matches the selector
+func (s Selector) Matches(meta *AccountMeta) bool {
+	if meta == nil {
+		meta = &AccountMeta{}
+	}
+	for _, tag := range s.Tags {
+		if !slices.ContainsFunc(meta.Tags, func(item string) bool { return strings.EqualFold(item, tag) }) {
+			return false
+		}
+	}
+	if len(s.Groups) == 0 {
+		return true
+	}
+	for _, group := range s.Groups {
+		if slices.ContainsFunc(meta.Groups, func(item string) bool { return strings.EqualFold(item, group) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag checks if the account has a tag
+func (a *AccountMeta) HasTag(tag string) bool {
+	return slices.ContainsFunc(a.Tags, func(item string) bool { return strings.EqualFold(item, tag) })
+}
+
+// AddTag adds a tag if it's not present
+func (a *AccountMeta) AddTag(tag string) {
+	a.Tags = addFold(a.Tags, tag)
+}
+
+// RemoveTag removes a tag
+func (a *AccountMeta) RemoveTag(tag string) {
+	a.Tags = removeFold(a.Tags, tag)
+}
+
+// AddGroup adds the account to a group
+func (a *AccountMeta) AddGroup(group string) {
+	a.Groups = addFold(a.Groups, group)
+}
+
+// RemoveGroup removes the account from a group
+func (a *AccountMeta) RemoveGroup(group string) {
+	a.Groups = removeFold(a.Groups, group)
+}
+
+// loadMetadata loads the sidecar file (empty metadata if it doesn't exist)
+func (m *Manager) loadMetadata() error {
+	m.metadata = &Metadata{
+		Accounts:    make(map[string]*AccountMeta),
+		Aliases:     make(map[string]string),
+		PriceFloors: make(map[string]float64),
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.maFilesPath, metadataFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	if err := json.Unmarshal(data, m.metadata); err != nil {
+		return fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	if m.metadata.Accounts == nil {
+		m.metadata.Accounts = make(map[string]*AccountMeta)
+	}
+	if m.metadata.Aliases == nil {
+		m.metadata.Aliases = make(map[string]string)
+	}
+	// Floors written before keys were normalized; of names differing only in
+	// case the highest floor is kept
+	floors := make(map[string]float64, len(m.metadata.PriceFloors))
+	for item, floor := range m.metadata.PriceFloors {
+		key := PriceFloorKey(item)
+		if existing, ok := floors[key]; !ok || floor > existing {
+			floors[key] = floor
+		}
+	}
+	m.metadata.PriceFloors = floors
+
+	return nil
+}
+
+// forgetMetadataUnlocked drops metadata, aliases and the default of a removed account
+func (m *Manager) forgetMetadataUnlocked(accountName string) {
+	delete(m.metadata.Accounts, accountName)
+	for alias, name := range m.metadata.Aliases {
+		if name == accountName {
+			delete(m.metadata.Aliases, alias)
+		}
+	}
+	if m.metadata.Default == accountName {
+		m.metadata.Default = ""
+	}
+}
+
+// saveMetadataUnlocked saves the sidecar file without locking (for internal use)
+func (m *Manager) saveMetadataUnlocked() error {
+	data, err := json.MarshalIndent(m.metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize metadata: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(m.maFilesPath, metadataFilename), data, 0600); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetadata returns a copy of the account's metadata
+func (m *Manager) GetMetadata(accountName string) AccountMeta {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	meta, ok := m.metadata.Accounts[accountName]
+	if !ok {
+		return AccountMeta{}
+	}
+
+	result := *meta
+	result.Tags = append([]string(nil), meta.Tags...)
+	result.Groups = append([]string(nil), meta.Groups...)
+	return result
+}
+
+// SetMetadata replaces the account's metadata and saves the sidecar file
+func (m *Manager) SetMetadata(accountName string, meta AccountMeta) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[accountName]; !ok {
+		return fmt.Errorf("account %s not found", accountName)
+	}
+
+	m.metadata.Accounts[accountName] = &meta
+	return m.saveMetadataUnlocked()
+}
+
+// GetPriceFloors returns a copy of the item to price floor map
+func (m *Manager) GetPriceFloors() map[string]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	floors := make(map[string]float64, len(m.metadata.PriceFloors))
+	for item, floor := range m.metadata.PriceFloors {
+		floors[item] = floor
+	}
+	return floors
+}
+
+// SetPriceFloor sets the price floor of an item, or the default floor for DefaultPriceFloor
+func (m *Manager) SetPriceFloor(item string, floor float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if PriceFloorKey(item) == "" {
+		return fmt.Errorf("item name is empty")
+	}
+	if floor < 0 {
+		return fmt.Errorf("price floor can't be negative")
+	}
+
+	m.metadata.PriceFloors[PriceFloorKey(item)] = floor
+	return m.saveMetadataUnlocked()
+}
+
+// RemovePriceFloor removes the price floor of an item
+func (m *Manager) RemovePriceFloor(item string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := PriceFloorKey(item)
+	if _, ok := m.metadata.PriceFloors[key]; !ok {
+		return fmt.Errorf("no price floor for %s", item)
+	}
+
+	delete(m.metadata.PriceFloors, key)
+	return m.saveMetadataUnlocked()
+}
+
+// SelectAccounts returns the accounts matching the selector
+func (m *Manager) SelectAccounts(selector Selector) []*SteamGuardAccount {
+	var selected []*SteamGuardAccount
+	for _, account := range m.GetAllAccounts() {
+		m.mu.RLock()
+		meta := m.metadata.Accounts[account.AccountName]
+		m.mu.RUnlock()
+
+		if selector.Matches(meta) {
+			selected = append(selected, account)
+		}
+	}
+	return selected
+}
+
+// addFold appends a value if the list doesn't contain it
+func addFold(list []string, value string) []string {
+	if value == "" || slices.ContainsFunc(list, func(item string) bool { return strings.EqualFold(item, value) }) {
+		return list
+	}
+	return append(list, value)
+}
+
+// removeFold removes a value from the list, ignoring case
+func removeFold(list []string, value string) []string {
+	result := list[:0]
+	for _, item := range list {
+		if !strings.EqualFold(item, value) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
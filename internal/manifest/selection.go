@@ -0,0 +1,166 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrAmbiguousAccount is returned when a query matches several accounts
+var ErrAmbiguousAccount = errors.New("ambiguous account")
+
+// GetAccount returns an account by query, or the default account if the query is empty.
+//
+// The query is resolved in order: exact account name, alias, SteamID,
+// list index (1-based, in manifest order) and finally a unique
+// case-insensitive prefix of an account name or alias.
+func (m *Manager) GetAccount(query string) (*SteamGuardAccount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.order) == 0 {
+		return nil, fmt.Errorf("no accounts")
+	}
+
+	if query == "" {
+		if account, ok := m.accounts[m.metadata.Default]; ok {
+			return account, nil
+		}
+		// Return the first account in manifest order
+		return m.accounts[m.order[0]], nil
+	}
+
+	if account, ok := m.accounts[query]; ok {
+		return account, nil
+	}
+	if name, ok := m.metadata.Aliases[query]; ok {
+		if account, ok := m.accounts[name]; ok {
+			return account, nil
+		}
+	}
+
+	// Case-insensitive exact name or alias
+	var matches []string
+	for _, name := range m.order {
+		if strings.EqualFold(name, query) {
+			matches = addFold(matches, name)
+		}
+	}
+	for alias, name := range m.metadata.Aliases {
+		if _, ok := m.accounts[name]; ok && strings.EqualFold(alias, query) {
+			matches = addFold(matches, name)
+		}
+	}
+	if account, err := m.uniqueMatch(query, matches); account != nil || err != nil {
+		return account, err
+	}
+
+	for _, name := range m.order {
+		if m.accounts[name].Session.SteamID == query {
+			return m.accounts[name], nil
+		}
+	}
+
+	if index, err := strconv.Atoi(query); err == nil && index >= 1 && index <= len(m.order) {
+		return m.accounts[m.order[index-1]], nil
+	}
+
+	// Unique prefix of a name or alias
+	matches = nil
+	lowerQuery := strings.ToLower(query)
+	for _, name := range m.order {
+		if strings.HasPrefix(strings.ToLower(name), lowerQuery) {
+			matches = addFold(matches, name)
+		}
+	}
+	for alias, name := range m.metadata.Aliases {
+		if _, ok := m.accounts[name]; ok && strings.HasPrefix(strings.ToLower(alias), lowerQuery) {
+			matches = addFold(matches, name)
+		}
+	}
+	if account, err := m.uniqueMatch(query, matches); account != nil || err != nil {
+		return account, err
+	}
+
+	return nil, fmt.Errorf("account %s not found", query)
+}
+
+// uniqueMatch returns the only match, an ambiguity error, or nothing
+func (m *Manager) uniqueMatch(query string, matches []string) (*SteamGuardAccount, error) {
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return m.accounts[matches[0]], nil
+	default:
+		sort.Strings(matches)
+		return nil, fmt.Errorf("%w %q, matches: %s", ErrAmbiguousAccount, query, strings.Join(matches, ", "))
+	}
+}
+
+// GetDefault returns the name of the default account ("" if not set)
+func (m *Manager) GetDefault() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.metadata.Default
+}
+
+// SetDefault sets the default account ("" resets to the first account)
+func (m *Manager) SetDefault(accountName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[accountName]; accountName != "" && !ok {
+		return fmt.Errorf("account %s not found", accountName)
+	}
+
+	m.metadata.Default = accountName
+	return m.saveMetadataUnlocked()
+}
+
+// GetAliases returns a copy of the alias to account name map
+func (m *Manager) GetAliases() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	aliases := make(map[string]string, len(m.metadata.Aliases))
+	for alias, name := range m.metadata.Aliases {
+		aliases[alias] = name
+	}
+	return aliases
+}
+
+// SetAlias points an alias at an account
+func (m *Manager) SetAlias(alias, accountName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if alias == "" {
+		return fmt.Errorf("alias is empty")
+	}
+	if _, ok := m.accounts[accountName]; !ok {
+		return fmt.Errorf("account %s not found", accountName)
+	}
+	// An alias shadowing another account's name would make that account unreachable
+	if _, ok := m.accounts[alias]; ok && alias != accountName {
+		return fmt.Errorf("alias %s is the name of another account", alias)
+	}
+
+	m.metadata.Aliases[alias] = accountName
+	return m.saveMetadataUnlocked()
+}
+
+// RemoveAlias removes an alias
+func (m *Manager) RemoveAlias(alias string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.metadata.Aliases[alias]; !ok {
+		return fmt.Errorf("alias %s not found", alias)
+	}
+
+	delete(m.metadata.Aliases, alias)
+	return m.saveMetadataUnlocked()
+}
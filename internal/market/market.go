@@ -0,0 +1,118 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/rules"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// ErrBelowFloor is returned for listings priced below their floor or with an unknown price
+var ErrBelowFloor = errors.New("below price floor")
+
+// Listing a pending market listing confirmation with its price
+type Listing struct {
+	Account      string                 `json:"account"`
+	Confirmation *steamapi.Confirmation `json:"confirmation"`
+	ItemName     string                 `json:"item_name"`
+	AppID        int                    `json:"appid,omitempty"`
+	BuyerPays    string                 `json:"buyer_pays,omitempty"`
+	YouReceive   string                 `json:"you_receive,omitempty"`
+	// Price what the buyer pays, or what the seller receives when that's all the page shows
+	Price float64 `json:"price"`
+	// Receive what the seller receives
+	Receive    float64 `json:"receive"`
+	PriceKnown bool    `json:"price_known"`
+	// Floor lowest price the item is accepted at, nil without a floor
+	Floor *float64 `json:"floor,omitempty"`
+}
+
+// NewListing builds a listing from a confirmation and its details, which may be nil
+func NewListing(account string, conf *steamapi.Confirmation, details *steamapi.ConfirmationDetails, floors map[string]float64) *Listing {
+	listing := &Listing{
+		Account:      account,
+		Confirmation: conf,
+		ItemName:     conf.Headline,
+	}
+
+	if details != nil && details.Listing != nil {
+		if details.Listing.ItemName != "" {
+			listing.ItemName = details.Listing.ItemName
+		}
+		listing.AppID = details.Listing.AppID
+		listing.BuyerPays = details.Listing.BuyerPays
+		listing.YouReceive = details.Listing.YouReceive
+
+		listing.Receive, _ = rules.ParsePrice(listing.YouReceive)
+		if listing.Price, listing.PriceKnown = rules.ParsePrice(listing.BuyerPays); !listing.PriceKnown {
+			listing.Price, listing.PriceKnown = rules.ParsePrice(listing.YouReceive)
+		}
+	}
+
+	if floor, ok := FloorFor(floors, listing.ItemName); ok {
+		listing.Floor = &floor
+	}
+	return listing
+}
+
+// FloorFor returns the floor of an item, falling back to the default floor.
+// The floors are keyed by manifest.PriceFloorKey.
+func FloorFor(floors map[string]float64, item string) (float64, bool) {
+	if floor, ok := floors[manifest.PriceFloorKey(item)]; ok {
+		return floor, true
+	}
+	floor, ok := floors[manifest.DefaultPriceFloor]
+	return floor, ok
+}
+
+// Check returns nil when the listing may be accepted: its price is known
+// and not below its floor
+func (l *Listing) Check() error {
+	if !l.PriceKnown {
+		return fmt.Errorf("%w: price of %s is unknown", ErrBelowFloor, l.ItemName)
+	}
+	if l.Floor != nil && l.Price < *l.Floor {
+		return fmt.Errorf("%w: %s listed at %.2f, floor is %.2f", ErrBelowFloor, l.ItemName, l.Price, *l.Floor)
+	}
+	return nil
+}
+
+// CheckFloor checks a market listing confirmation against the price floors
+// before it's accepted. Other confirmations, and every listing while no
+// floors are set, pass; details is only called for listings.
+func CheckFloor(account string, conf *steamapi.Confirmation, details func() (*steamapi.ConfirmationDetails, error), floors map[string]float64) error {
+	if conf.Type != steamapi.ConfTypeMarketListing || len(floors) == 0 {
+		return nil
+	}
+	loaded, err := details()
+	if err != nil {
+		return fmt.Errorf("%w: price of %s is unknown: %v", ErrBelowFloor, conf.Description(), err)
+	}
+	return NewListing(account, conf, loaded, floors).Check()
+}
+
+// Totals sum of listings
+type Totals struct {
+	Count   int     `json:"count"`
+	Price   float64 `json:"price"`
+	Receive float64 `json:"receive"`
+	// Unknown listings whose price couldn't be determined, not part of the sums
+	Unknown int `json:"unknown,omitempty"`
+}
+
+// Sum adds up the prices of listings
+func Sum(listings []*Listing) Totals {
+	var totals Totals
+	for _, listing := range listings {
+		totals.Count++
+		if !listing.PriceKnown {
+			totals.Unknown++
+			continue
+		}
+		totals.Price += listing.Price
+		totals.Receive += listing.Receive
+	}
+	return totals
+}
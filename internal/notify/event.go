@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// Event types
+const (
+	EventConfirmationNew      = "confirmation.new"
+	EventConfirmationAccepted = "confirmation.accepted"
+	EventConfirmationRejected = "confirmation.rejected"
+	EventConfirmationFailed   = "confirmation.failed"
+	EventSessionExpired       = "session.expired"
+	EventAuthenticatorRevoked = "authenticator.revoked"
+	EventCodeGenerated        = "code.generated"
+	EventAccountAdded         = "account.added"
+)
+
+// EventTypes all event types, in the order they're documented
+var EventTypes = []string{
+	EventConfirmationNew,
+	EventConfirmationAccepted,
+	EventConfirmationRejected,
+	EventConfirmationFailed,
+	EventSessionExpired,
+	EventAuthenticatorRevoked,
+	EventCodeGenerated,
+	EventAccountAdded,
+}
+
+// optInEvents are only received by notifiers that list them explicitly
+var optInEvents = []string{EventCodeGenerated}
+
+// Event something that happened to an account. The ID is derived from what
+// the event is about, so the same event always gets the same ID.
+type Event struct {
+	ID           string        `json:"id"`
+	Type         string        `json:"type"`
+	Time         time.Time     `json:"time"`
+	Account      string        `json:"account"`
+	Confirmation *Confirmation `json:"confirmation,omitempty"`
+	// Source who acted on the confirmation: manual or rule
+	Source string `json:"source,omitempty"`
+	// Detail what made the decision, e.g. the matching rule
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Confirmation the confirmation an event is about
+type Confirmation struct {
+	ID        string   `json:"id"`
+	CreatorID string   `json:"creator_id,omitempty"`
+	Type      string   `json:"type"`
+	Headline  string   `json:"headline"`
+	Summary   []string `json:"summary,omitempty"`
+}
+
+// ConfirmationEvent builds an event about a confirmation
+func ConfirmationEvent(eventType, account string, conf *steamapi.Confirmation) Event {
+	return Event{
+		ID:      eventID(eventType, account, conf.ID),
+		Type:    eventType,
+		Time:    time.Now().UTC(),
+		Account: account,
+		Confirmation: &Confirmation{
+			ID:        conf.ID,
+			CreatorID: conf.CreatorID,
+			Type:      conf.Type.String(),
+			Headline:  conf.Description(),
+			Summary:   conf.Summary,
+		},
+	}
+}
+
+// SessionExpiredEvent builds an event about an account's session that
+// couldn't be renewed. It's reported once per session.
+func SessionExpiredEvent(account *manifest.SteamGuardAccount, err error) Event {
+	session := account.Session.RefreshToken
+	if session == "" {
+		session = account.Session.SteamLoginSecure
+	}
+	event := Event{
+		ID:      eventID(EventSessionExpired, account.AccountName, session),
+		Type:    EventSessionExpired,
+		Time:    time.Now().UTC(),
+		Account: account.AccountName,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// AuthenticatorRevokedEvent builds an event about an authenticator Steam
+// no longer accepts. It's reported once per authenticator.
+func AuthenticatorRevokedEvent(account *manifest.SteamGuardAccount, err error) Event {
+	event := Event{
+		ID:      eventID(EventAuthenticatorRevoked, account.AccountName, account.SerialNumber),
+		Type:    EventAuthenticatorRevoked,
+		Time:    time.Now().UTC(),
+		Account: account.AccountName,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// CodeGeneratedEvent builds an event about a generated login code. The code
+// itself is not part of the event. Every generation is a new event.
+func CodeGeneratedEvent(account *manifest.SteamGuardAccount) Event {
+	now := time.Now().UTC()
+	return Event{
+		ID:      eventID(EventCodeGenerated, account.AccountName, now.Format(time.RFC3339Nano)),
+		Type:    EventCodeGenerated,
+		Time:    now,
+		Account: account.AccountName,
+	}
+}
+
+// AccountAddedEvent builds an event about an account whose authenticator
+// was set up. It's reported once per authenticator.
+func AccountAddedEvent(account *manifest.SteamGuardAccount) Event {
+	return Event{
+		ID:      eventID(EventAccountAdded, account.AccountName, account.SerialNumber),
+		Type:    EventAccountAdded,
+		Time:    time.Now().UTC(),
+		Account: account.AccountName,
+	}
+}
+
+// eventID hashes what identifies an event
+func eventID(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultHookTimeout is used when a hook has no timeout
+const DefaultHookTimeout = 30 * time.Second
+
+// hookLogFilename exit status of every hook run
+const hookLogFilename = "hooks.log"
+
+// hookOutputLimit how much of a failed hook's output ends up in the error
+const hookOutputLimit = 512
+
+// HookConfig an executable run for events
+type HookConfig struct {
+	// Command path of the executable, relative to the maFiles directory
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Filter
+	// TimeoutSeconds after which the hook is killed, DefaultHookTimeout when zero
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// Hook runs an executable with the event as JSON on stdin and its fields in
+// STEAMGUARD_* environment variables
+type Hook struct {
+	Config HookConfig
+	// Dir maFiles directory, the working directory of the hook and home of hooks.log
+	Dir string
+}
+
+// NewHook creates a hook sink
+func NewHook(config HookConfig, dir string) *Hook {
+	return &Hook{Config: config, Dir: dir}
+}
+
+// Name implements Sink
+func (h *Hook) Name() string {
+	return "hook " + h.Config.Command
+}
+
+// Wants implements Sink
+func (h *Hook) Wants(event Event) bool {
+	return h.Config.Filter.Matches(event)
+}
+
+// Send implements Sink. The run is logged to hooks.log; a non-zero exit
+// status or a timeout is an error.
+func (h *Hook) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	timeout := time.Duration(h.Config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	command := h.Config.Command
+	if !filepath.IsAbs(command) && strings.ContainsRune(command, filepath.Separator) {
+		command = filepath.Join(h.Dir, command)
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, h.Config.Args...)
+	cmd.Dir = h.Dir
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.Env = append(os.Environ(), hookEnv(event, h.Dir)...)
+	// Don't wait for children that inherited the output pipes
+	cmd.WaitDelay = time.Second
+
+	started := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(started).Round(time.Millisecond)
+
+	status := "exit 0"
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		status = fmt.Sprintf("killed after %s timeout", timeout)
+		err = fmt.Errorf("timed out after %s", timeout)
+	case err != nil:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			status = fmt.Sprintf("exit %d", exitErr.ExitCode())
+		} else {
+			status = err.Error()
+		}
+		if out := strings.TrimSpace(output.String()); out != "" {
+			if len(out) > hookOutputLimit {
+				out = out[:hookOutputLimit] + "…"
+			}
+			err = fmt.Errorf("%w: %s", err, out)
+		}
+	}
+	h.log(event, status, elapsed)
+	return err
+}
+
+// log appends one run to hooks.log
+func (h *Hook) log(event Event, status string, elapsed time.Duration) {
+	file, err := os.OpenFile(filepath.Join(h.Dir, hookLogFilename), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintf(file, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		time.Now().UTC().Format(time.RFC3339), h.Config.Command, event.Type, event.Account, status, elapsed)
+}
+
+// hookEnv the event's fields as environment variables
+func hookEnv(event Event, dir string) []string {
+	env := []string{
+		"STEAMGUARD_EVENT=" + event.Type,
+		"STEAMGUARD_EVENT_ID=" + event.ID,
+		"STEAMGUARD_EVENT_TIME=" + event.Time.Format(time.RFC3339),
+		"STEAMGUARD_ACCOUNT=" + event.Account,
+		"STEAMGUARD_MAFILES=" + dir,
+	}
+	if event.Source != "" {
+		env = append(env, "STEAMGUARD_SOURCE="+event.Source)
+	}
+	if event.Error != "" {
+		env = append(env, "STEAMGUARD_ERROR="+event.Error)
+	}
+	if conf := event.Confirmation; conf != nil {
+		env = append(env,
+			"STEAMGUARD_CONFIRMATION_ID="+conf.ID,
+			"STEAMGUARD_CONFIRMATION_TYPE="+conf.Type,
+			"STEAMGUARD_CREATOR_ID="+conf.CreatorID,
+		)
+	}
+	return env
+}
@@ -0,0 +1,343 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Mail modes
+const (
+	MailImmediate = "immediate"
+	MailDigest    = "digest"
+)
+
+// DefaultDigestInterval is used when the SMTP config has no digest interval
+const DefaultDigestInterval = time.Hour
+
+// digestFilename events waiting for the next digest
+const digestFilename = "mail-digest.json"
+
+// DefaultSubjectTemplate subject of mails without a custom template
+const DefaultSubjectTemplate = `{{if .Digest}}steamguard: {{len .Events}} event(s){{else}}{{with index .Events 0}}steamguard: {{.Type}} on {{.Account}}{{end}}{{end}}`
+
+// DefaultBodyTemplate body of mails without a custom template
+const DefaultBodyTemplate = `{{range .Events}}{{.Time.Local.Format "2006-01-02 15:04:05"}}  {{.Type}}  {{.Account}}
+{{- with .Confirmation}}
+    {{.Headline}} ({{.Type}}, ID {{.ID}}{{if .CreatorID}}, creator {{.CreatorID}}{{end}})
+{{- range .Summary}}
+    {{.}}
+{{- end}}
+{{- end}}
+{{- if .Detail}}
+    {{.Detail}}
+{{- end}}
+{{- if .Error}}
+    Error: {{.Error}}
+{{- end}}
+
+{{end}}`
+
+// SMTPConfig an SMTP server mails about events are sent through
+type SMTPConfig struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// StartTLS upgrades the connection with STARTTLS, required when set
+	StartTLS bool `json:"starttls,omitempty"`
+	// TLS connects with implicit TLS, usually on port 465
+	TLS      bool   `json:"tls,omitempty"`
+	Username string `json:"username,omitempty"`
+	// Password of the user, STEAMGUARD_SMTP_PASSWORD overrides it
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	// Mode immediate sends one mail per event, digest collects them
+	Mode string `json:"mode,omitempty"`
+	// DigestMinutes least time between digests, DefaultDigestInterval when zero
+	DigestMinutes int `json:"digest_minutes,omitempty"`
+	Filter
+	// SubjectTemplate and BodyTemplate are text/template sources, BodyFile a
+	// template file relative to the maFiles directory
+	SubjectTemplate string `json:"subject_template,omitempty"`
+	BodyTemplate    string `json:"body_template,omitempty"`
+	BodyFile        string `json:"body_file,omitempty"`
+}
+
+// Validate checks the settings needed to send mail
+func (c *SMTPConfig) Validate() error {
+	switch {
+	case c.Host == "":
+		return fmt.Errorf("smtp host is not set")
+	case c.Port <= 0:
+		return fmt.Errorf("smtp port is not set")
+	case c.From == "":
+		return fmt.Errorf("smtp sender is not set")
+	case len(c.To) == 0:
+		return fmt.Errorf("smtp has no recipients")
+	case c.TLS && c.StartTLS:
+		return fmt.Errorf("use either tls or starttls")
+	case c.Mode != "" && c.Mode != MailImmediate && c.Mode != MailDigest:
+		return fmt.Errorf("unknown mail mode %q (%s or %s)", c.Mode, MailImmediate, MailDigest)
+	}
+	return nil
+}
+
+// MailData what the templates are rendered with
+type MailData struct {
+	Events []Event
+	Digest bool
+}
+
+// Mailer sends events by mail, one by one or as digests
+type Mailer struct {
+	Config SMTPConfig
+	// Dir maFiles directory, holding the digest queue and template files
+	Dir string
+}
+
+// NewMailer creates a mail sink
+func NewMailer(config SMTPConfig, dir string) *Mailer {
+	return &Mailer{Config: config, Dir: dir}
+}
+
+// Name implements Sink
+func (m *Mailer) Name() string {
+	return "smtp " + net.JoinHostPort(m.Config.Host, strconv.Itoa(m.Config.Port))
+}
+
+// Wants implements Sink
+func (m *Mailer) Wants(event Event) bool {
+	return m.Config.Filter.Matches(event)
+}
+
+// Send implements Sink. In digest mode the event is queued for Flush.
+func (m *Mailer) Send(event Event) error {
+	if m.Config.Mode != MailDigest {
+		return m.SendMail(MailData{Events: []Event{event}})
+	}
+
+	digest, err := m.loadDigest()
+	if err != nil {
+		return err
+	}
+	digest.Events = append(digest.Events, event)
+	return m.saveDigest(digest)
+}
+
+// Flush sends the queued events as one digest once the digest interval has
+// passed since the oldest of them and since the previous digest. force sends
+// right away.
+func (m *Mailer) Flush(force bool) error {
+	if m.Config.Mode != MailDigest {
+		return nil
+	}
+
+	digest, err := m.loadDigest()
+	if err != nil {
+		return err
+	}
+	if len(digest.Events) == 0 {
+		return nil
+	}
+
+	interval := time.Duration(m.Config.DigestMinutes) * time.Minute
+	if interval <= 0 {
+		interval = DefaultDigestInterval
+	}
+	if !force && (time.Since(digest.Events[0].Time) < interval || time.Since(digest.LastSent) < interval) {
+		return nil
+	}
+
+	if err := m.SendMail(MailData{Events: digest.Events, Digest: true}); err != nil {
+		return err
+	}
+	return m.saveDigest(&mailDigest{LastSent: time.Now().UTC()})
+}
+
+// SendMail renders the templates and sends one mail
+func (m *Mailer) SendMail(data MailData) error {
+	subject, body, err := m.render(data)
+	if err != nil {
+		return err
+	}
+	return m.deliver(m.message(subject, body))
+}
+
+// render renders subject and body
+func (m *Mailer) render(data MailData) (string, string, error) {
+	subjectSource := m.Config.SubjectTemplate
+	if subjectSource == "" {
+		subjectSource = DefaultSubjectTemplate
+	}
+	bodySource := m.Config.BodyTemplate
+	if m.Config.BodyFile != "" {
+		path := m.Config.BodyFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(m.Dir, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read mail template: %w", err)
+		}
+		bodySource = string(content)
+	}
+	if bodySource == "" {
+		bodySource = DefaultBodyTemplate
+	}
+
+	subject, err := renderTemplate("subject", subjectSource, data)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := renderTemplate("body", bodySource, data)
+	if err != nil {
+		return "", "", err
+	}
+	// A subject spanning lines would inject headers
+	return strings.Join(strings.Fields(subject), " "), body, nil
+}
+
+// renderTemplate parses and executes one template
+func renderTemplate(name, source string, data MailData) (string, error) {
+	tmpl, err := template.New(name).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// message builds the RFC 5322 message
+func (m *Mailer) message(subject, body string) []byte {
+	id := make([]byte, 12)
+	rand.Read(id)
+	domain := m.Config.From[strings.LastIndex(m.Config.From, "@")+1:]
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.Config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(m.Config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// deliver sends the message through the SMTP server
+func (m *Mailer) deliver(msg []byte) error {
+	addr := net.JoinHostPort(m.Config.Host, strconv.Itoa(m.Config.Port))
+	tlsConfig := &tls.Config{ServerName: m.Config.Host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if m.Config.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	client, err := smtp.NewClient(conn, m.Config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if m.Config.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s doesn't support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if m.Config.Username != "" {
+		password := m.Config.Password
+		if env := os.Getenv("STEAMGUARD_SMTP_PASSWORD"); env != "" {
+			password = env
+		}
+		// PlainAuth refuses unencrypted connections, except to localhost
+		if err := client.Auth(smtp.PlainAuth("", m.Config.Username, password, m.Config.Host)); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.Config.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, to := range m.Config.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", to, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := writer.Write(msg); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return client.Quit()
+}
+
+// mailDigest represents mail-digest.json
+type mailDigest struct {
+	LastSent time.Time `json:"last_sent"`
+	Events   []Event   `json:"events"`
+}
+
+// loadDigest reads the queued events
+func (m *Mailer) loadDigest() (*mailDigest, error) {
+	digest := &mailDigest{}
+	data, err := os.ReadFile(filepath.Join(m.Dir, digestFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return digest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mail digest: %w", err)
+	}
+	if err := json.Unmarshal(data, digest); err != nil {
+		return nil, fmt.Errorf("failed to parse mail digest: %w", err)
+	}
+	return digest, nil
+}
+
+// saveDigest writes the queued events
+func (m *Mailer) saveDigest(digest *mailDigest) error {
+	data, err := json.MarshalIndent(digest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mail digest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.Dir, digestFilename), data, 0600); err != nil {
+		return fmt.Errorf("failed to write mail digest: %w", err)
+	}
+	return nil
+}
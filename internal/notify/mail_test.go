@@ -0,0 +1,301 @@
+package notify
+
+import (
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receivedMail one mail accepted by fakeSMTP
+type receivedMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTP a minimal SMTP server recording the mails it receives
+type fakeSMTP struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	mails []receivedMail
+}
+
+// startFakeSMTP listens on a local port until the test ends
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTP{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// serve answers one SMTP session
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 fake ESMTP")
+
+	var mail receivedMail
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			text.PrintfLine("250 fake")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			mail = receivedMail{From: trimAddress(line[len("MAIL FROM:"):])}
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			mail.To = append(mail.To, trimAddress(line[len("RCPT TO:"):]))
+			text.PrintfLine("250 OK")
+		case command == "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.Data = string(data)
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case command == "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+// received returns the mails received so far
+func (s *fakeSMTP) received() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.mails...)
+}
+
+// config returns SMTP settings pointing at the server
+func (s *fakeSMTP) config() SMTPConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return SMTPConfig{
+		Host: "127.0.0.1",
+		Port: addr.Port,
+		From: "steamguard@example.com",
+		To:   []string{"ops@example.com", "oncall@example.com"},
+	}
+}
+
+// trimAddress strips the angle brackets and parameters of an address
+func trimAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if i := strings.IndexByte(address, ' '); i >= 0 {
+		address = address[:i]
+	}
+	return strings.Trim(address, "<>")
+}
+
+// headerOf returns a header of a received message, whose line endings
+// ReadDotBytes turned into \n
+func headerOf(data, name string) string {
+	head, _, _ := strings.Cut(data, "\n\n")
+	for _, line := range strings.Split(head, "\n") {
+		if key, value, ok := strings.Cut(line, ": "); ok && strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// testEvent returns an event of the account at the time
+func testEvent(account string, at time.Time) Event {
+	return Event{
+		ID:      account + "-" + strconv.FormatInt(at.UnixNano(), 10),
+		Type:    EventConfirmationNew,
+		Time:    at,
+		Account: account,
+		Confirmation: &Confirmation{
+			ID:       "1234567890",
+			Type:     "trade",
+			Headline: "Trade with Someone",
+		},
+	}
+}
+
+func TestMailerSendImmediate(t *testing.T) {
+	server := startFakeSMTP(t)
+	mailer := NewMailer(server.config(), t.TempDir())
+
+	if err := mailer.Send(testEvent("bot1", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	mails := server.received()
+	if len(mails) != 1 {
+		t.Fatalf("received %d mails, want 1", len(mails))
+	}
+	mail := mails[0]
+	if mail.From != "steamguard@example.com" {
+		t.Errorf("MAIL FROM = %q", mail.From)
+	}
+	if strings.Join(mail.To, ",") != "ops@example.com,oncall@example.com" {
+		t.Errorf("RCPT TO = %v", mail.To)
+	}
+	if got := headerOf(mail.Data, "Subject"); got != "steamguard: confirmation.new on bot1" {
+		t.Errorf("Subject = %q", got)
+	}
+	if got := headerOf(mail.Data, "To"); got != "ops@example.com, oncall@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if !strings.Contains(mail.Data, "Trade with Someone (trade, ID 1234567890)") {
+		t.Errorf("body is missing the confirmation:\n%s", mail.Data)
+	}
+}
+
+func TestMailerRenderStripsHeaderInjection(t *testing.T) {
+	config := SMTPConfig{SubjectTemplate: "Alert {{(index .Events 0).Account}}"}
+	mailer := NewMailer(config, t.TempDir())
+
+	event := testEvent("bot1\r\nBcc: attacker@example.com\n", time.Now())
+	subject, _, err := mailer.render(MailData{Events: []Event{event}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsAny(subject, "\r\n") {
+		t.Fatalf("subject %q spans lines", subject)
+	}
+	if subject != "Alert bot1 Bcc: attacker@example.com" {
+		t.Errorf("subject = %q", subject)
+	}
+}
+
+func TestMailerDigest(t *testing.T) {
+	server := startFakeSMTP(t)
+	config := server.config()
+	config.Mode = MailDigest
+	config.DigestMinutes = 30
+	dir := t.TempDir()
+	mailer := NewMailer(config, dir)
+
+	// Queued, not sent
+	if err := mailer.Send(testEvent("bot1", time.Now().Add(-10*time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+	if err := mailer.Send(testEvent("bot2", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.received()); n != 0 {
+		t.Fatalf("digest mode sent %d mails on Send", n)
+	}
+
+	// The oldest event is younger than the interval
+	if err := mailer.Flush(false); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.received()); n != 0 {
+		t.Fatalf("flush before the interval sent %d mails", n)
+	}
+
+	// Once the oldest event is older than the interval, both go in one mail
+	digest, err := mailer.loadDigest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest.Events[0].Time = time.Now().Add(-time.Hour)
+	if err := mailer.saveDigest(digest); err != nil {
+		t.Fatal(err)
+	}
+	if err := mailer.Flush(false); err != nil {
+		t.Fatal(err)
+	}
+	mails := server.received()
+	if len(mails) != 1 {
+		t.Fatalf("received %d mails, want 1 digest", len(mails))
+	}
+	if got := headerOf(mails[0].Data, "Subject"); got != "steamguard: 2 event(s)" {
+		t.Errorf("Subject = %q", got)
+	}
+	if !strings.Contains(mails[0].Data, "bot1") || !strings.Contains(mails[0].Data, "bot2") {
+		t.Errorf("digest is missing events:\n%s", mails[0].Data)
+	}
+
+	// The queue is cleared and the send time kept
+	digest, err = mailer.loadDigest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digest.Events) != 0 {
+		t.Errorf("%d events left in the queue", len(digest.Events))
+	}
+	if time.Since(digest.LastSent) > time.Minute {
+		t.Errorf("LastSent = %s, want now", digest.LastSent)
+	}
+
+	// A new old event waits for the interval since the last digest
+	if err := mailer.Send(testEvent("bot3", time.Now().Add(-time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+	if err := mailer.Flush(false); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.received()); n != 1 {
+		t.Fatalf("flush within the interval of the last digest sent a mail, %d total", n)
+	}
+
+	// force sends right away
+	if err := mailer.Flush(true); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.received()); n != 2 {
+		t.Fatalf("forced flush: %d mails total, want 2", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, digestFilename)); err != nil {
+		t.Errorf("digest file: %v", err)
+	}
+}
+
+func TestMailerDigestKeptOnFailure(t *testing.T) {
+	server := startFakeSMTP(t)
+	config := server.config()
+	config.Mode = MailDigest
+	mailer := NewMailer(config, t.TempDir())
+
+	if err := mailer.Send(testEvent("bot1", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	server.listener.Close()
+
+	if err := mailer.Flush(true); err == nil {
+		t.Fatal("flush to a closed server succeeded")
+	}
+	digest, err := mailer.loadDigest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(digest.Events) != 1 {
+		t.Errorf("%d events queued after a failed flush, want 1", len(digest.Events))
+	}
+}
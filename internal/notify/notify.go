@@ -0,0 +1,302 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ConfigFilename notification settings in the maFiles directory
+const ConfigFilename = "notifications.json"
+
+// stateFilename IDs of events that were already delivered
+const stateFilename = "notify-state.json"
+
+// stateRetention how long delivered event IDs are remembered
+const stateRetention = 90 * 24 * time.Hour
+
+// Retries of events a sink failed to receive: the first after retryBackoff,
+// doubled up to maxRetryBackoff, until the event is retryLimit old
+const (
+	retryBackoff    = time.Minute
+	maxRetryBackoff = time.Hour
+	retryLimit      = 24 * time.Hour
+)
+
+// Config represents notifications.json
+type Config struct {
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+	SMTP     *SMTPConfig     `json:"smtp,omitempty"`
+	Hooks    []HookConfig    `json:"hooks,omitempty"`
+}
+
+// Filter selects the events a notifier receives. Empty lists select
+// everything, except events like code.generated that must be listed.
+type Filter struct {
+	// Accounts account names; empty for all accounts
+	Accounts []string `json:"accounts,omitempty"`
+	// Events event types; empty for all events
+	Events []string `json:"events,omitempty"`
+}
+
+// Matches checks if the event passes the filter
+func (f Filter) Matches(event Event) bool {
+	if len(f.Accounts) > 0 && !slices.ContainsFunc(f.Accounts, func(item string) bool { return strings.EqualFold(item, event.Account) }) {
+		return false
+	}
+	if len(f.Events) == 0 {
+		return !slices.ContainsFunc(optInEvents, func(item string) bool { return strings.EqualFold(item, event.Type) })
+	}
+	return slices.ContainsFunc(f.Events, func(item string) bool { return strings.EqualFold(item, event.Type) })
+}
+
+// LoadConfig reads notifications.json; a missing file is an empty config
+func LoadConfig(dir string) (*Config, error) {
+	config := &Config{}
+	data, err := os.ReadFile(filepath.Join(dir, ConfigFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse notifications: %w", err)
+	}
+	return config, nil
+}
+
+// SaveConfig writes notifications.json
+func SaveConfig(dir string, config *Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode notifications: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConfigFilename), data, 0600); err != nil {
+		return fmt.Errorf("failed to write notifications: %w", err)
+	}
+	return nil
+}
+
+// Sink delivers events somewhere
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	// Wants checks if the sink receives the event
+	Wants(event Event) bool
+	// Send delivers one event
+	Send(event Event) error
+}
+
+// Flusher is a sink that batches events
+type Flusher interface {
+	// Flush delivers the batched events when they're due, or right away when forced
+	Flush(force bool) error
+}
+
+// Dispatcher sends events to the configured sinks, each event only once
+type Dispatcher struct {
+	Dir    string
+	Sinks  []Sink
+	Logger *log.Logger
+}
+
+// New creates a dispatcher for the notifications configured in the directory
+func New(dir string, logger *log.Logger) (*Dispatcher, error) {
+	config, err := LoadConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatcher := &Dispatcher{Dir: dir, Logger: logger}
+	for _, webhook := range config.Webhooks {
+		dispatcher.Sinks = append(dispatcher.Sinks, NewWebhook(webhook))
+	}
+	if config.SMTP != nil {
+		if err := config.SMTP.Validate(); err != nil {
+			return nil, err
+		}
+		dispatcher.Sinks = append(dispatcher.Sinks, NewMailer(*config.SMTP, dir))
+	}
+	for _, hook := range config.Hooks {
+		dispatcher.Sinks = append(dispatcher.Sinks, NewHook(hook, dir))
+	}
+	return dispatcher, nil
+}
+
+// Dispatch delivers events that weren't dispatched before. Failures are
+// logged, and the sinks that failed are retried by later calls of Dispatch
+// and Flush.
+func (d *Dispatcher) Dispatch(events ...Event) {
+	if d == nil || len(d.Sinks) == 0 || len(events) == 0 {
+		return
+	}
+
+	state, err := loadState(d.Dir)
+	if err != nil {
+		d.Logger.Printf("notifications: %v", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, event := range events {
+		if _, seen := state.Delivered[event.ID]; seen {
+			continue
+		}
+		state.Delivered[event.ID] = now
+		if failed := d.send(event, nil); len(failed) > 0 {
+			state.Retries = append(state.Retries, &retry{Event: event, Sinks: failed, Attempts: 1, NextAt: now.Add(retryBackoff)})
+		}
+	}
+
+	if err := state.save(d.Dir, now); err != nil {
+		d.Logger.Printf("notifications: %v", err)
+	}
+	d.Flush(false)
+}
+
+// retryFailed delivers the events whose retry is due to the sinks that
+// failed them, all of them when forced
+func (d *Dispatcher) retryFailed(force bool) {
+	state, err := loadState(d.Dir)
+	if err != nil {
+		d.Logger.Printf("notifications: %v", err)
+		return
+	}
+	if len(state.Retries) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	kept := state.Retries[:0]
+	for _, r := range state.Retries {
+		if !force && now.Before(r.NextAt) {
+			kept = append(kept, r)
+			continue
+		}
+		if r.Sinks = d.send(r.Event, r.Sinks); len(r.Sinks) == 0 {
+			continue
+		}
+		if now.Sub(r.Event.Time) > retryLimit {
+			d.Logger.Printf("notifications: giving up on %s of %s after %d attempts", r.Event.Type, r.Event.Account, r.Attempts+1)
+			continue
+		}
+		backoff := retryBackoff << r.Attempts
+		if backoff <= 0 || backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+		r.Attempts++
+		r.NextAt = now.Add(backoff)
+		kept = append(kept, r)
+	}
+	state.Retries = kept
+
+	if err := state.save(d.Dir, now); err != nil {
+		d.Logger.Printf("notifications: %v", err)
+	}
+}
+
+// Announce delivers events that can't repeat, like code.generated, without
+// remembering them. Failures are logged.
+func (d *Dispatcher) Announce(events ...Event) {
+	if d == nil || len(d.Sinks) == 0 {
+		return
+	}
+	for _, event := range events {
+		d.send(event, nil)
+	}
+	d.Flush(false)
+}
+
+// send delivers one event to the sinks that want it, only to the named
+// sinks when names isn't nil. Returns the names of the sinks that failed.
+func (d *Dispatcher) send(event Event, names []string) []string {
+	var failed []string
+	for _, sink := range d.Sinks {
+		if !sink.Wants(event) || (names != nil && !slices.Contains(names, sink.Name())) {
+			continue
+		}
+		if err := sink.Send(event); err != nil {
+			d.Logger.Printf("%s: failed to deliver %s of %s: %v", sink.Name(), event.Type, event.Account, err)
+			failed = append(failed, sink.Name())
+		}
+	}
+	return failed
+}
+
+// Flush retries failed deliveries and lets batching sinks deliver what's
+// due, everything when forced. Failures are logged.
+func (d *Dispatcher) Flush(force bool) {
+	if d == nil || len(d.Sinks) == 0 {
+		return
+	}
+	d.retryFailed(force)
+	for _, sink := range d.Sinks {
+		if flusher, ok := sink.(Flusher); ok {
+			if err := flusher.Flush(force); err != nil {
+				d.Logger.Printf("%s: %v", sink.Name(), err)
+			}
+		}
+	}
+}
+
+// state represents notify-state.json
+type state struct {
+	Delivered map[string]time.Time `json:"delivered"`
+	Retries   []*retry             `json:"retries,omitempty"`
+}
+
+// retry an event some sinks failed to receive
+type retry struct {
+	Event Event `json:"event"`
+	// Sinks names of the sinks still to receive the event
+	Sinks    []string  `json:"sinks"`
+	Attempts int       `json:"attempts"`
+	NextAt   time.Time `json:"next_at"`
+}
+
+// loadState reads the delivered event IDs
+func loadState(dir string) (*state, error) {
+	s := &state{}
+	data, err := os.ReadFile(filepath.Join(dir, stateFilename))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read notification state: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("failed to parse notification state: %w", err)
+		}
+	}
+	if s.Delivered == nil {
+		s.Delivered = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// save forgets old event IDs and writes the state atomically
+func (s *state) save(dir string, now time.Time) error {
+	for id, at := range s.Delivered {
+		if now.Sub(at) > stateRetention {
+			delete(s.Delivered, id)
+		}
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification state: %w", err)
+	}
+	path := filepath.Join(dir, stateFilename)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("failed to write notification state: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write notification state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+// fakeSink records the events it receives, failing while down
+type fakeSink struct {
+	name string
+	down bool
+	sent []string
+}
+
+func (s *fakeSink) Name() string { return s.name }
+
+func (s *fakeSink) Wants(event Event) bool { return true }
+
+func (s *fakeSink) Send(event Event) error {
+	if s.down {
+		return errors.New("down")
+	}
+	s.sent = append(s.sent, event.ID)
+	return nil
+}
+
+// testDispatcher returns a dispatcher of the sinks in a temporary directory
+func testDispatcher(t *testing.T, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{Dir: t.TempDir(), Sinks: sinks, Logger: log.New(io.Discard, "", 0)}
+}
+
+func TestDispatchOnce(t *testing.T) {
+	sink := &fakeSink{name: "a"}
+	d := testDispatcher(t, sink)
+
+	event := testEvent("bot1", time.Now())
+	d.Dispatch(event)
+	d.Dispatch(event)
+	if len(sink.sent) != 1 {
+		t.Errorf("event sent %d times, want once", len(sink.sent))
+	}
+}
+
+func TestDispatchRetriesFailedSinks(t *testing.T) {
+	up := &fakeSink{name: "up"}
+	down := &fakeSink{name: "down", down: true}
+	d := testDispatcher(t, up, down)
+
+	event := testEvent("bot1", time.Now())
+	d.Dispatch(event)
+	if len(up.sent) != 1 || len(down.sent) != 0 {
+		t.Fatalf("sent up %d, down %d; want 1, 0", len(up.sent), len(down.sent))
+	}
+
+	state, err := loadState(d.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Retries) != 1 || len(state.Retries[0].Sinks) != 1 || state.Retries[0].Sinks[0] != "down" {
+		t.Fatalf("retries = %+v, want the event for the failed sink", state.Retries)
+	}
+
+	// Not due yet, and the same event dispatched again isn't a new delivery
+	down.down = false
+	d.Dispatch(event)
+	d.Flush(false)
+	if len(down.sent) != 0 {
+		t.Fatalf("retried before the backoff: %v", down.sent)
+	}
+
+	d.Flush(true)
+	if len(down.sent) != 1 {
+		t.Errorf("failed sink received %d events after the retry, want 1", len(down.sent))
+	}
+	if len(up.sent) != 1 {
+		t.Errorf("working sink received the event %d times, want once", len(up.sent))
+	}
+	if state, _ := loadState(d.Dir); len(state.Retries) != 0 {
+		t.Errorf("%d retries left after delivery", len(state.Retries))
+	}
+}
+
+func TestRetryBackoffAndLimit(t *testing.T) {
+	sink := &fakeSink{name: "a", down: true}
+	d := testDispatcher(t, sink)
+
+	d.Dispatch(testEvent("bot1", time.Now()))
+	d.Flush(true)
+	state, err := loadState(d.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Retries) != 1 {
+		t.Fatalf("%d retries, want 1", len(state.Retries))
+	}
+	r := state.Retries[0]
+	if r.Attempts != 2 {
+		t.Errorf("attempts = %d, want 2", r.Attempts)
+	}
+	if wait := time.Until(r.NextAt); wait < retryBackoff || wait > 2*retryBackoff+time.Second {
+		t.Errorf("next retry in %s, want about %s", wait, 2*retryBackoff)
+	}
+
+	// An event older than the limit is dropped
+	r.Event.Time = time.Now().Add(-2 * retryLimit)
+	if err := state.save(d.Dir, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	d.Flush(true)
+	if state, _ := loadState(d.Dir); len(state.Retries) != 0 {
+		t.Errorf("%d retries left past the limit", len(state.Retries))
+	}
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Webhook delivery defaults
+const (
+	DefaultMaxAttempts = 4
+	DefaultBackoff     = time.Second
+	maxBackoff         = time.Minute
+	// maxSendTime bounds the retries of one Send, so a webhook that is down
+	// doesn't hold up the caller; the dispatcher retries the event later
+	maxSendTime = 15 * time.Second
+)
+
+// Headers sent with every webhook delivery
+const (
+	HeaderSignature = "X-Steamguard-Signature"
+	HeaderEvent     = "X-Steamguard-Event"
+	HeaderDelivery  = "X-Steamguard-Delivery"
+)
+
+// WebhookConfig a webhook URL receiving events as JSON
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// Secret shared secret the payload is signed with
+	Secret string `json:"secret"`
+	Filter
+	// MaxAttempts deliveries tried before giving up, DefaultMaxAttempts when zero
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// BackoffSeconds wait before the first retry, doubled for every further one
+	BackoffSeconds int `json:"backoff_seconds,omitempty"`
+}
+
+// Webhook posts events to a URL, signed with HMAC-SHA256
+type Webhook struct {
+	Config WebhookConfig
+	Client *http.Client
+}
+
+// NewWebhook creates a webhook sink
+func NewWebhook(config WebhookConfig) *Webhook {
+	return &Webhook{
+		Config: config,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Sink
+func (w *Webhook) Name() string {
+	return "webhook " + w.Config.URL
+}
+
+// Wants implements Sink
+func (w *Webhook) Wants(event Event) bool {
+	return w.Config.Filter.Matches(event)
+}
+
+// Send implements Sink. Network errors, 429 and 5xx responses are retried
+// with exponential backoff for up to maxSendTime; other responses fail
+// right away.
+func (w *Webhook) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	attempts := w.Config.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+	backoff := time.Duration(w.Config.BackoffSeconds) * time.Second
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	start := time.Now()
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		retry, err := w.post(event, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry || attempt == attempts || time.Since(start)+backoff > maxSendTime {
+			break
+		}
+
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return lastErr
+}
+
+// post delivers the payload once, reporting whether a failure may be retried
+func (w *Webhook) post(event Event, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.Config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "steamguard-cli")
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, event.ID)
+	req.Header.Set(HeaderSignature, Sign(w.Config.Secret, time.Now().Unix(), body))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("status %d", resp.StatusCode)
+}
+
+// Sign returns the signature header value "t=<timestamp>,v1=<hex>", where
+// v1 is the HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret.
+// Receivers recompute it and reject old timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	ts := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%s,v1=%s", ts, hex.EncodeToString(mac.Sum(nil)))
+}
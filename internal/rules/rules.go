@@ -0,0 +1,407 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// Action what to do with a confirmation
+type Action int
+
+const (
+	ActionIgnore Action = iota
+	ActionAccept
+	ActionReject
+)
+
+// String returns the name of the action
+func (a Action) String() string {
+	switch a {
+	case ActionAccept:
+		return "accept"
+	case ActionReject:
+		return "reject"
+	default:
+		return "ignore"
+	}
+}
+
+// ParseAction parses a rule action
+func ParseAction(action string) (Action, error) {
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "accept", "allow", "confirm":
+		return ActionAccept, nil
+	case "reject", "cancel", "deny":
+		return ActionReject, nil
+	case "ignore", "skip", "":
+		return ActionIgnore, nil
+	}
+	return ActionIgnore, fmt.Errorf("unknown action %q (accept, reject or ignore)", action)
+}
+
+// Rule compiled auto-confirm rule
+type Rule struct {
+	Name   string
+	Action Action
+	// AllowHold accepting trades that would be held
+	AllowHold bool
+
+	types         []steamapi.ConfirmationType
+	creators      []string
+	match         *regexp.Regexp
+	accountTags   []string
+	accountGroups []string
+	minPrice      *float64
+	maxPrice      *float64
+	partnerAllow  []string
+	partnerDeny   []string
+	maxItemsGiven *int
+	timeOfDay     *timeRange
+}
+
+// Input everything a rule can be matched against
+type Input struct {
+	Account       string
+	AccountTags   []string
+	AccountGroups []string
+	Confirmation  *steamapi.Confirmation
+	// Now time used for time of day conditions, the current time when zero
+	Now time.Time
+	// Details loads the confirmation details, only called when a rule needs them
+	Details func() (*steamapi.ConfirmationDetails, error)
+
+	details    *steamapi.ConfirmationDetails
+	detailsErr error
+	loaded     bool
+}
+
+// Result outcome of evaluating rules
+type Result struct {
+	Action Action
+	// Rule index of the matching rule, -1 when no rule matched
+	Rule int
+	Name string
+}
+
+// Source describes which rule produced the result
+func (r Result) Source() string {
+	if r.Rule < 0 {
+		return "no matching rule"
+	}
+	if r.Name != "" {
+		return fmt.Sprintf("rule %d (%s)", r.Rule+1, r.Name)
+	}
+	return fmt.Sprintf("rule %d", r.Rule+1)
+}
+
+// Compile validates and compiles rules of the manifest
+func Compile(defs []manifest.AutoConfirmRule) ([]*Rule, error) {
+	compiled := make([]*Rule, 0, len(defs))
+	for i, def := range defs {
+		rule, err := compileRule(def)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		compiled = append(compiled, rule)
+	}
+	return compiled, nil
+}
+
+// compileRule compiles one rule
+func compileRule(def manifest.AutoConfirmRule) (*Rule, error) {
+	action, err := ParseAction(def.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &Rule{
+		Name:          def.Name,
+		Action:        action,
+		AllowHold:     def.AllowHold,
+		creators:      def.Creators,
+		accountTags:   def.AccountTags,
+		accountGroups: def.AccountGroups,
+		minPrice:      def.MinPrice,
+		maxPrice:      def.MaxPrice,
+		partnerAllow:  def.PartnerAllow,
+		partnerDeny:   def.PartnerDeny,
+		maxItemsGiven: def.MaxItemsGiven,
+	}
+
+	typeNames := def.Types
+	if def.Type != "" {
+		typeNames = append([]string{def.Type}, typeNames...)
+	}
+	for _, name := range typeNames {
+		confType, err := steamapi.ParseConfirmationType(name)
+		if err != nil {
+			return nil, err
+		}
+		if action == ActionAccept && confType.Risk() == steamapi.RiskHigh {
+			return nil, fmt.Errorf("%s confirmations are high-risk and never accepted automatically", confType)
+		}
+		rule.types = append(rule.types, confType)
+	}
+
+	if def.Match != "" {
+		if rule.match, err = regexp.Compile(def.Match); err != nil {
+			return nil, fmt.Errorf("invalid match: %w", err)
+		}
+	}
+
+	if def.TimeOfDay != "" {
+		if rule.timeOfDay, err = parseTimeRange(def.TimeOfDay); err != nil {
+			return nil, err
+		}
+	}
+
+	return rule, nil
+}
+
+// Evaluate returns the action of the first matching rule.
+// Confirmations matching no rule are ignored.
+func Evaluate(rules []*Rule, in *Input) (Result, error) {
+	for i, rule := range rules {
+		matched, err := rule.Matches(in)
+		if err != nil {
+			return Result{Action: ActionIgnore, Rule: i, Name: rule.Name}, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if matched {
+			return Result{Action: rule.Action, Rule: i, Name: rule.Name}, nil
+		}
+	}
+	return Result{Action: ActionIgnore, Rule: -1}, nil
+}
+
+// Matches checks if all conditions of the rule match the input
+func (r *Rule) Matches(in *Input) (bool, error) {
+	conf := in.Confirmation
+
+	if len(r.types) > 0 && !containsType(r.types, conf.Type) {
+		return false, nil
+	}
+	if len(r.creators) > 0 && !slices.Contains(r.creators, conf.CreatorID) {
+		return false, nil
+	}
+	if r.match != nil && !r.match.MatchString(conf.Headline+"\n"+strings.Join(conf.Summary, "\n")) {
+		return false, nil
+	}
+
+	for _, tag := range r.accountTags {
+		if !slices.ContainsFunc(in.AccountTags, func(item string) bool { return strings.EqualFold(item, tag) }) {
+			return false, nil
+		}
+	}
+	if len(r.accountGroups) > 0 {
+		found := false
+		for _, group := range r.accountGroups {
+			if slices.ContainsFunc(in.AccountGroups, func(item string) bool { return strings.EqualFold(item, group) }) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	if r.timeOfDay != nil {
+		now := in.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		if !r.timeOfDay.contains(now) {
+			return false, nil
+		}
+	}
+
+	// Conditions needing the details page come last, so it's only fetched when necessary
+	if r.minPrice != nil || r.maxPrice != nil {
+		price, ok, err := in.price()
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+		if r.minPrice != nil && price < *r.minPrice {
+			return false, nil
+		}
+		if r.maxPrice != nil && price > *r.maxPrice {
+			return false, nil
+		}
+	}
+
+	if len(r.partnerAllow) > 0 || len(r.partnerDeny) > 0 {
+		partner, err := in.partner()
+		if err != nil {
+			return false, err
+		}
+		// An unknown partner never passes partner conditions
+		if partner == "" {
+			return false, nil
+		}
+		if len(r.partnerAllow) > 0 && !slices.Contains(r.partnerAllow, partner) {
+			return false, nil
+		}
+		if slices.Contains(r.partnerDeny, partner) {
+			return false, nil
+		}
+	}
+
+	if r.maxItemsGiven != nil {
+		given, ok, err := in.itemsGiven()
+		if err != nil {
+			return false, err
+		}
+		if !ok || given > *r.maxItemsGiven {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// LoadDetails loads the details once, for the rules and the checks after them
+func (in *Input) LoadDetails() (*steamapi.ConfirmationDetails, error) {
+	if !in.loaded {
+		in.loaded = true
+		if in.Details == nil {
+			in.detailsErr = fmt.Errorf("confirmation details are not available")
+		} else {
+			in.details, in.detailsErr = in.Details()
+		}
+	}
+	return in.details, in.detailsErr
+}
+
+// price returns the listing price: what the buyer pays, or what the seller receives
+func (in *Input) price() (float64, bool, error) {
+	details, err := in.LoadDetails()
+	if err != nil {
+		return 0, false, err
+	}
+	if details.Listing == nil {
+		return 0, false, nil
+	}
+
+	text := details.Listing.BuyerPays
+	if text == "" {
+		text = details.Listing.YouReceive
+	}
+	price, ok := ParsePrice(text)
+	return price, ok, nil
+}
+
+// partner returns the partner's SteamID, from the trade offer if it was fetched
+func (in *Input) partner() (string, error) {
+	if offer := in.Confirmation.Offer; offer != nil {
+		return offer.PartnerSteamID, nil
+	}
+
+	details, err := in.LoadDetails()
+	if err != nil {
+		return "", err
+	}
+	if details.Partner == nil {
+		return "", nil
+	}
+	return details.Partner.SteamID, nil
+}
+
+// itemsGiven returns how many items the trade takes from the account
+func (in *Input) itemsGiven() (int, bool, error) {
+	if offer := in.Confirmation.Offer; offer != nil {
+		return len(offer.ItemsToGive), true, nil
+	}
+	if in.Confirmation.Type != steamapi.ConfTypeTrade {
+		return 0, false, nil
+	}
+
+	details, err := in.LoadDetails()
+	if err != nil {
+		return 0, false, err
+	}
+	if details.Given == nil && details.Received == nil {
+		return 0, false, nil
+	}
+	return len(details.Given), true, nil
+}
+
+// ParsePrice parses a formatted price like "$1,234.56", "2,50€" or "1 234,56 pуб."
+func ParsePrice(text string) (float64, bool) {
+	var digits strings.Builder
+	for _, r := range text {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+			digits.WriteRune(r)
+		}
+	}
+	number := strings.Trim(digits.String(), ".,")
+	if number == "" {
+		return 0, false
+	}
+
+	// The last separator is decimal if followed by one or two digits
+	if i := strings.LastIndexAny(number, ".,"); i >= 0 {
+		if len(number)-i-1 <= 2 {
+			number = strings.NewReplacer(".", "", ",", "").Replace(number[:i]) + "." + number[i+1:]
+		} else {
+			number = strings.NewReplacer(".", "", ",", "").Replace(number)
+		}
+	}
+
+	price, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, false
+	}
+	return price, true
+}
+
+// timeRange time of day range in minutes since midnight
+type timeRange struct {
+	from, to int
+}
+
+// parseTimeRange parses "09:00-18:00"
+func parseTimeRange(text string) (*timeRange, error) {
+	parts := strings.Split(text, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid time_of_day %q, expected HH:MM-HH:MM", text)
+	}
+
+	var minutes [2]int
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid time_of_day %q, expected HH:MM-HH:MM", text)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	return &timeRange{from: minutes[0], to: minutes[1]}, nil
+}
+
+// contains checks if the time of day is in the range, wrapping midnight when from > to
+func (r *timeRange) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if r.from <= r.to {
+		return minute >= r.from && minute < r.to
+	}
+	return minute >= r.from || minute < r.to
+}
+
+// containsType checks if a list contains a confirmation type
+func containsType(list []steamapi.ConfirmationType, value steamapi.ConfirmationType) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
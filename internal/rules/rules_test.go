@@ -0,0 +1,197 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		text  string
+		price float64
+		ok    bool
+	}{
+		{"$1,234.56", 1234.56, true},
+		{"2,50€", 2.50, true},
+		{"1 234,56 pуб.", 1234.56, true},
+		{"1,234", 1234, true},
+		{"$0.03", 0.03, true},
+		{"", 0, false},
+		{"free", 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := ParsePrice(tt.text)
+		if ok != tt.ok || price != tt.price {
+			t.Errorf("ParsePrice(%q) = %v, %v; want %v, %v", tt.text, price, ok, tt.price, tt.ok)
+		}
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	tests := []struct {
+		text     string
+		from, to int
+		wantErr  bool
+	}{
+		{"09:00-18:00", 9 * 60, 18 * 60, false},
+		{"22:00 - 06:30", 22 * 60, 6*60 + 30, false},
+		{"00:00-23:59", 0, 23*60 + 59, false},
+		{"09:00", 0, 0, true},
+		{"09:00-18:00-20:00", 0, 0, true},
+		{"9am-5pm", 0, 0, true},
+		{"25:00-26:00", 0, 0, true},
+		{"", 0, 0, true},
+	}
+	for _, tt := range tests {
+		r, err := parseTimeRange(tt.text)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseTimeRange(%q) = %+v, want an error", tt.text, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTimeRange(%q) error: %v", tt.text, err)
+			continue
+		}
+		if r.from != tt.from || r.to != tt.to {
+			t.Errorf("parseTimeRange(%q) = %d-%d, want %d-%d", tt.text, r.from, r.to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestTimeRangeContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 1, hour, minute, 0, 0, time.Local)
+	}
+	day := &timeRange{from: 9 * 60, to: 18 * 60}
+	night := &timeRange{from: 22 * 60, to: 6 * 60}
+
+	tests := []struct {
+		name string
+		r    *timeRange
+		t    time.Time
+		want bool
+	}{
+		{"day start", day, at(9, 0), true},
+		{"day middle", day, at(12, 30), true},
+		{"day end excluded", day, at(18, 0), false},
+		{"before day", day, at(8, 59), false},
+		{"night start", night, at(22, 0), true},
+		{"before midnight", night, at(23, 59), true},
+		{"midnight", night, at(0, 0), true},
+		{"after midnight", night, at(5, 59), true},
+		{"night end excluded", night, at(6, 0), false},
+		{"outside night", night, at(12, 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.r.contains(tt.t); got != tt.want {
+			t.Errorf("%s: contains(%s) = %v, want %v", tt.name, tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateFirstMatchWins(t *testing.T) {
+	ruleset, err := Compile([]manifest.AutoConfirmRule{
+		{Name: "reject scam", Type: "trade", Match: "(?i)knife", Action: "reject"},
+		{Name: "trades", Type: "trade", Action: "accept"},
+		{Name: "everything", Action: "reject"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		conf     *steamapi.Confirmation
+		action   Action
+		rule     int
+		ruleName string
+	}{
+		{"first rule", &steamapi.Confirmation{Type: steamapi.ConfTypeTrade, Headline: "Trade with Knife"}, ActionReject, 0, "reject scam"},
+		{"second rule", &steamapi.Confirmation{Type: steamapi.ConfTypeTrade, Headline: "Trade"}, ActionAccept, 1, "trades"},
+		{"catch-all", &steamapi.Confirmation{Type: steamapi.ConfTypeMarketListing, Headline: "Knife"}, ActionReject, 2, "everything"},
+	}
+	for _, tt := range tests {
+		result, err := Evaluate(ruleset, &Input{Confirmation: tt.conf})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if result.Action != tt.action || result.Rule != tt.rule || result.Name != tt.ruleName {
+			t.Errorf("%s: got %s by rule %d (%s), want %s by rule %d (%s)",
+				tt.name, result.Action, result.Rule, result.Name, tt.action, tt.rule, tt.ruleName)
+		}
+	}
+
+	result, err := Evaluate(ruleset[:2], &Input{Confirmation: &steamapi.Confirmation{Type: steamapi.ConfTypeMarketListing}})
+	if err != nil || result.Action != ActionIgnore || result.Rule != -1 {
+		t.Errorf("no match: got %s by rule %d, %v; want ignore by no rule", result.Action, result.Rule, err)
+	}
+}
+
+func TestCompileRejectsHighRiskAccept(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     manifest.AutoConfirmRule
+		wantErr bool
+	}{
+		{"accept apikey", manifest.AutoConfirmRule{Type: "apikey", Action: "accept"}, true},
+		{"accept trade and phone", manifest.AutoConfirmRule{Types: []string{"trade", "phone"}, Action: "accept"}, true},
+		{"reject apikey", manifest.AutoConfirmRule{Type: "apikey", Action: "reject"}, false},
+		{"ignore phone", manifest.AutoConfirmRule{Type: "phone", Action: "ignore"}, false},
+		{"accept trade", manifest.AutoConfirmRule{Type: "trade", Action: "accept"}, false},
+		{"accept market", manifest.AutoConfirmRule{Type: "market", Action: "accept"}, false},
+	}
+	for _, tt := range tests {
+		_, err := Compile([]manifest.AutoConfirmRule{tt.def})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Compile error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "high-risk") {
+			t.Errorf("%s: error %q doesn't mention high-risk", tt.name, err)
+		}
+	}
+}
+
+func TestDetailsLoadedOnlyWhenNeeded(t *testing.T) {
+	maxPrice := 5.0
+	tests := []struct {
+		name  string
+		defs  []manifest.AutoConfirmRule
+		loads int
+	}{
+		{"type only", []manifest.AutoConfirmRule{{Type: "market", Action: "accept"}}, 0},
+		{"type mismatch before price", []manifest.AutoConfirmRule{{Type: "trade", MaxPrice: &maxPrice, Action: "accept"}}, 0},
+		{"price", []manifest.AutoConfirmRule{{Type: "market", MaxPrice: &maxPrice, Action: "accept"}}, 1},
+		{"loaded once for two rules", []manifest.AutoConfirmRule{
+			{MinPrice: &maxPrice, Action: "reject"},
+			{MaxPrice: &maxPrice, Action: "accept"},
+		}, 1},
+	}
+	for _, tt := range tests {
+		ruleset, err := Compile(tt.defs)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		loads := 0
+		in := &Input{
+			Confirmation: &steamapi.Confirmation{Type: steamapi.ConfTypeMarketListing},
+			Details: func() (*steamapi.ConfirmationDetails, error) {
+				loads++
+				return &steamapi.ConfirmationDetails{Listing: &steamapi.ListingPrice{BuyerPays: "$2.50"}}, nil
+			},
+		}
+		if _, err := Evaluate(ruleset, in); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if loads != tt.loads {
+			t.Errorf("%s: details loaded %d times, want %d", tt.name, loads, tt.loads)
+		}
+	}
+}
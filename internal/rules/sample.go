@@ -0,0 +1,67 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/steamapi"
+)
+
+// Sample a confirmation to test rules against, with an optional expected action
+type Sample struct {
+	Account       string                        `json:"account,omitempty"`
+	AccountTags   []string                      `json:"account_tags,omitempty"`
+	AccountGroups []string                      `json:"account_groups,omitempty"`
+	Time          *time.Time                    `json:"time,omitempty"`
+	Confirmation  *steamapi.Confirmation        `json:"confirmation"`
+	Details       *steamapi.ConfirmationDetails `json:"details,omitempty"`
+	Expect        string                        `json:"expect,omitempty"`
+}
+
+// LoadSamples reads a JSON array of samples
+func LoadSamples(path string) ([]Sample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read samples: %w", err)
+	}
+
+	var samples []Sample
+	if err := json.Unmarshal(data, &samples); err != nil {
+		return nil, fmt.Errorf("failed to parse samples: %w", err)
+	}
+
+	for i, sample := range samples {
+		if sample.Confirmation == nil {
+			return nil, fmt.Errorf("sample %d has no confirmation", i+1)
+		}
+		if sample.Expect != "" {
+			if _, err := ParseAction(sample.Expect); err != nil {
+				return nil, fmt.Errorf("sample %d: %w", i+1, err)
+			}
+		}
+	}
+
+	return samples, nil
+}
+
+// Input builds the rule input of the sample
+func (s Sample) Input() *Input {
+	in := &Input{
+		Account:       s.Account,
+		AccountTags:   s.AccountTags,
+		AccountGroups: s.AccountGroups,
+		Confirmation:  s.Confirmation,
+		Details: func() (*steamapi.ConfirmationDetails, error) {
+			if s.Details == nil {
+				return &steamapi.ConfirmationDetails{}, nil
+			}
+			return s.Details, nil
+		},
+	}
+	if s.Time != nil {
+		in.Now = s.Time.Local()
+	}
+	return in
+}
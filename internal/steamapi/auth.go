@@ -0,0 +1,189 @@
+package steamapi
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+// GuardCodeType is the kind of Steam Guard code requested during login
+type GuardCodeType int
+
+const (
+	GuardCodeNone       GuardCodeType = 1
+	GuardCodeEmail      GuardCodeType = 2
+	GuardCodeDevice     GuardCodeType = 3
+	GuardCodeDeviceConf GuardCodeType = 4
+	GuardCodeEmailConf  GuardCodeType = 5
+)
+
+// GuardCodeProvider returns a Steam Guard code of the requested kind
+type GuardCodeProvider func(kind GuardCodeType) (string, error)
+
+// Login performs Steam login through IAuthenticationService
+// and returns a mobile session with access and refresh tokens
+func (c *Client) Login(username, password string, codes GuardCodeProvider) (*manifest.SessionData, error) {
+	// Get RSA key for password encryption
+	var rsaKey struct {
+		Mod       string `json:"publickey_mod"`
+		Exp       string `json:"publickey_exp"`
+		Timestamp string `json:"timestamp"`
+	}
+	params := url.Values{}
+	params.Set("account_name", username)
+	if err := c.callService(http.MethodGet, "IAuthenticationService/GetPasswordRSAPublicKey", "", params, &rsaKey); err != nil {
+		return nil, fmt.Errorf("failed to get RSA key: %w", err)
+	}
+
+	encryptedPassword, err := encryptPassword(password, rsaKey.Mod, rsaKey.Exp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start auth session
+	var session struct {
+		ClientID             string  `json:"client_id"`
+		RequestID            string  `json:"request_id"`
+		Interval             float64 `json:"interval"`
+		SteamID              string  `json:"steamid"`
+		AllowedConfirmations []struct {
+			Type GuardCodeType `json:"confirmation_type"`
+		} `json:"allowed_confirmations"`
+	}
+	params = url.Values{}
+	params.Set("account_name", username)
+	params.Set("encrypted_password", encryptedPassword)
+	params.Set("encryption_timestamp", rsaKey.Timestamp)
+	params.Set("remember_login", "true")
+	params.Set("platform_type", "3")
+	params.Set("persistence", "1")
+	params.Set("website_id", "Mobile")
+	params.Set("device_friendly_name", "steamguard-cli")
+	if err := c.callService(http.MethodPost, "IAuthenticationService/BeginAuthSessionViaCredentials", "", params, &session); err != nil {
+		return nil, fmt.Errorf("failed to begin auth session: %w", err)
+	}
+	if session.ClientID == "" {
+		return nil, fmt.Errorf("login failed: invalid username or password")
+	}
+
+	// Submit Steam Guard code if one is required
+	for _, conf := range session.AllowedConfirmations {
+		if conf.Type != GuardCodeEmail && conf.Type != GuardCodeDevice {
+			continue
+		}
+		if codes == nil {
+			return nil, fmt.Errorf("login requires a Steam Guard code")
+		}
+
+		code, err := codes(conf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Steam Guard code: %w", err)
+		}
+
+		params = url.Values{}
+		params.Set("client_id", session.ClientID)
+		params.Set("steamid", session.SteamID)
+		params.Set("code", code)
+		params.Set("code_type", strconv.Itoa(int(conf.Type)))
+		if err := c.callService(http.MethodPost, "IAuthenticationService/UpdateAuthSessionWithSteamGuardCode", "", params, nil); err != nil {
+			return nil, fmt.Errorf("failed to submit Steam Guard code: %w", err)
+		}
+		break
+	}
+
+	// Poll for tokens
+	interval := time.Duration(session.Interval * float64(time.Second))
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		var status struct {
+			RefreshToken string `json:"refresh_token"`
+			AccessToken  string `json:"access_token"`
+		}
+		params = url.Values{}
+		params.Set("client_id", session.ClientID)
+		params.Set("request_id", session.RequestID)
+		if err := c.callService(http.MethodPost, "IAuthenticationService/PollAuthSessionStatus", "", params, &status); err != nil {
+			return nil, fmt.Errorf("failed to poll auth session: %w", err)
+		}
+
+		if status.AccessToken != "" {
+			return newSessionData(session.SteamID, status.AccessToken, status.RefreshToken)
+		}
+
+		time.Sleep(interval)
+	}
+
+	return nil, fmt.Errorf("login timed out waiting for confirmation")
+}
+
+// newSessionData builds session data with web cookies from mobile tokens
+func newSessionData(steamID, accessToken, refreshToken string) (*manifest.SessionData, error) {
+	sessionID := make([]byte, 12)
+	if _, err := rand.Read(sessionID); err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	return &manifest.SessionData{
+		SessionID:        hex.EncodeToString(sessionID),
+		SteamLoginSecure: steamID + "%7C%7C" + accessToken,
+		SteamID:          steamID,
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+	}, nil
+}
+
+// encryptPassword encrypts the password with Steam's RSA public key
+func encryptPassword(password, modHex, expHex string) (string, error) {
+	mod, ok := new(big.Int).SetString(modHex, 16)
+	if !ok {
+		return "", fmt.Errorf("invalid RSA modulus")
+	}
+	exp, err := strconv.ParseInt(expHex, 16, 32)
+	if err != nil {
+		return "", fmt.Errorf("invalid RSA exponent: %w", err)
+	}
+
+	pub := &rsa.PublicKey{N: mod, E: int(exp)}
+	encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, pub, []byte(password))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt password: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// RefreshAccessToken gets a new access token using the session's refresh token
+func (c *Client) RefreshAccessToken(session *manifest.SessionData) error {
+	if session.RefreshToken == "" {
+		return fmt.Errorf("session has no refresh token, login again")
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	params := url.Values{}
+	params.Set("refresh_token", session.RefreshToken)
+	params.Set("steamid", session.SteamID)
+	if err := c.callService(http.MethodPost, "IAuthenticationService/GenerateAccessTokenForApp", "", params, &result); err != nil {
+		return fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	if result.AccessToken == "" {
+		return fmt.Errorf("failed to refresh access token: refresh token is no longer valid")
+	}
+
+	session.AccessToken = result.AccessToken
+	session.SteamLoginSecure = session.SteamID + "%7C%7C" + result.AccessToken
+	return nil
+}
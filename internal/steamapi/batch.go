@@ -0,0 +1,165 @@
+package steamapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+// multiConfirmationChunk how many confirmations are sent in one multiajaxop request
+const multiConfirmationChunk = 25
+
+// ConfirmationResult outcome of responding to one confirmation
+type ConfirmationResult struct {
+	Confirmation *Confirmation
+	Err          error
+}
+
+// AcceptConfirmations accepts confirmations in batches. High-risk
+// confirmations, which are only accepted one by one with AcceptConfirmation,
+// and confirmations the Approver doesn't allow yet fail without being sent.
+func (c *Client) AcceptConfirmations(account *manifest.SteamGuardAccount, confs []*Confirmation) []ConfirmationResult {
+	blocked := make(map[*Confirmation]error)
+	var allowed []*Confirmation
+	for _, conf := range confs {
+		if err := CheckRisk(conf); err != nil {
+			blocked[conf] = err
+			continue
+		}
+		if c.Approver != nil {
+			if err := c.Approver.CheckAccept(account, conf); err != nil {
+				blocked[conf] = err
+				continue
+			}
+		}
+		allowed = append(allowed, conf)
+	}
+
+	accepted := make(map[*Confirmation]error)
+	for _, result := range c.respondToConfirmations(account, allowed, "allow") {
+		accepted[result.Confirmation] = result.Err
+		if result.Err == nil && c.Approver != nil && !c.DryRun {
+			if err := c.Approver.Accepted(account, result.Confirmation); err != nil {
+				accepted[result.Confirmation] = fmt.Errorf("confirmation accepted, but %w", err)
+			}
+		}
+	}
+
+	// Keep the order of the input
+	results := make([]ConfirmationResult, 0, len(confs))
+	for _, conf := range confs {
+		if err, ok := blocked[conf]; ok {
+			results = append(results, ConfirmationResult{Confirmation: conf, Err: err})
+			continue
+		}
+		results = append(results, ConfirmationResult{Confirmation: conf, Err: accepted[conf]})
+	}
+	return results
+}
+
+// RejectConfirmations rejects confirmations in batches
+func (c *Client) RejectConfirmations(account *manifest.SteamGuardAccount, confs []*Confirmation) []ConfirmationResult {
+	return c.respondToConfirmations(account, confs, "cancel")
+}
+
+// respondToConfirmations sends confirmations to multiajaxop in chunks.
+// When a chunk fails, its confirmations are retried one by one.
+func (c *Client) respondToConfirmations(account *manifest.SteamGuardAccount, confs []*Confirmation, op string) []ConfirmationResult {
+	results := make([]ConfirmationResult, 0, len(confs))
+
+	for start := 0; start < len(confs); start += multiConfirmationChunk {
+		end := start + multiConfirmationChunk
+		if end > len(confs) {
+			end = len(confs)
+		}
+		chunk := confs[start:end]
+
+		err := c.multiRespond(account, chunk, op)
+		if err == nil {
+			for _, conf := range chunk {
+				results = append(results, ConfirmationResult{Confirmation: conf})
+			}
+			continue
+		}
+
+		// Retrying one by one can't help if the session or account is unusable
+		if errors.Is(err, ErrNeedAuth) || errors.Is(err, manifest.ErrNotFullyEnrolled) {
+			for _, conf := range chunk {
+				results = append(results, ConfirmationResult{Confirmation: conf, Err: err})
+			}
+			continue
+		}
+
+		for _, conf := range chunk {
+			results = append(results, ConfirmationResult{
+				Confirmation: conf,
+				Err:          c.respondToConfirmation(account, conf, op),
+			})
+		}
+	}
+
+	return results
+}
+
+// multiRespond responds to several confirmations with one multiajaxop request
+func (c *Client) multiRespond(account *manifest.SteamGuardAccount, confs []*Confirmation, op string) error {
+	if err := account.CheckEnrolled(); err != nil {
+		return err
+	}
+	if c.DryRun {
+		return nil
+	}
+
+	params, err := confirmationParams(account, op)
+	if err != nil {
+		return err
+	}
+	params.Set("op", op)
+	for _, conf := range confs {
+		params.Add("cid[]", conf.ID)
+		params.Add("ck[]", conf.Nonce)
+	}
+
+	confURL := fmt.Sprintf("%s/mobileconf/multiajaxop", steamCommunityBase)
+
+	req, err := http.NewRequest("POST", confURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.addSessionCookies(req, account)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to respond to confirmations: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Success  bool `json:"success"`
+		NeedAuth bool `json:"needauth"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if result.NeedAuth {
+		return ErrNeedAuth
+	}
+	if !result.Success {
+		return fmt.Errorf("batch operation was not successful")
+	}
+
+	return nil
+}
@@ -12,12 +12,20 @@ import (
 	"github.com/devhooly/steamguard-go/internal/manifest"
 )
 
-const steamLoginBase = "https://login.steampowered.com"
-
+const (
+	steamCommunityBase = "https://steamcommunity.com"
+	steamAPIBase       = "https://api.steampowered.com"
+)
 
 // Client represents a client for working with Steam API
 type Client struct {
 	httpClient *http.Client
+	// DryRun performs every fetch but never responds to confirmations
+	DryRun bool
+	// Approver, if set, must allow every confirmation before it's accepted
+	Approver Approver
+	// Observer, if set, is told about every fetch of the confirmations
+	Observer Observer
 }
 
 // NewClient creates a new Steam API client
@@ -29,50 +37,27 @@ func NewClient() *Client {
 	}
 }
 
-// Confirmation represents a trade/market confirmation
-type Confirmation struct {
-	ID          string
-	Key         string
-	Description string
-	Type        string
-	Creator     string
-	Time        int64
-}
-
-// Login performs Steam login (simplified version)
-func (c *Client) Login(username, password string) (*manifest.SessionData, error) {
-	// NOTE: Full Steam login implementation is very complex and requires
-	// handling RSA encryption, captcha, email/mobile codes, etc.
-	// This is a simplified stub for demonstration purposes
-	
-	return nil, fmt.Errorf("Login function requires full Steam Auth protocol implementation")
+// GetConfirmations gets a list of pending confirmations and tells the Observer
+func (c *Client) GetConfirmations(account *manifest.SteamGuardAccount) ([]*Confirmation, error) {
+	confirmations, err := c.getConfirmations(account)
+	if c.Observer != nil {
+		c.Observer.ConfirmationsFetched(account, confirmations, err)
+	}
+	return confirmations, err
 }
 
+// getConfirmations fetches the pending confirmations
+func (c *Client) getConfirmations(account *manifest.SteamGuardAccount) ([]*Confirmation, error) {
+	if err := account.CheckEnrolled(); err != nil {
+		return nil, err
+	}
 
-
-// GetConfirmations gets a list of pending confirmations
-func (c *Client) GetConfirmations(account *manifest.SteamGuardAccount) ([]*Confirmation, error) {
-	timestamp := time.Now().Unix()
-	
-	// Generate hashes for confirmations
-	hashConf, err := account.GenerateConfirmationHash("conf", timestamp)
+	params, err := confirmationParams(account, "list")
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate conf hash: %w", err)
+		return nil, err
 	}
 
-	deviceID := account.GetDeviceID()
-	steamID := account.Session.SteamID
-
-	// Build URL
-	params := url.Values{}
-	params.Set("p", deviceID)
-	params.Set("a", steamID)
-	params.Set("k", hashConf)
-	params.Set("t", fmt.Sprintf("%d", timestamp))
-	params.Set("m", "android")
-	params.Set("tag", "conf")
-
-	confURL := fmt.Sprintf("%s/mobileconf/conf?%s", steamLoginBase, params.Encode())
+	confURL := fmt.Sprintf("%s/mobileconf/getlist?%s", steamCommunityBase, params.Encode())
 
 	// Execute request
 	req, err := http.NewRequest("GET", confURL, nil)
@@ -94,14 +79,59 @@ func (c *Client) GetConfirmations(account *manifest.SteamGuardAccount) ([]*Confi
 		return nil, fmt.Errorf("failed to get confirmations: %d - %s", resp.StatusCode, string(body))
 	}
 
-	// Parse response (simplified version, actual response is HTML)
-	// In reality, HTML page parsing is needed
-	return []*Confirmation{}, nil
+	var result struct {
+		Success  bool            `json:"success"`
+		NeedAuth bool            `json:"needauth"`
+		Message  string          `json:"message"`
+		Detail   string          `json:"detail"`
+		Conf     []*Confirmation `json:"conf"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse confirmations: %w", err)
+	}
+
+	if result.NeedAuth {
+		return nil, ErrNeedAuth
+	}
+	if !result.Success {
+		message := result.Message
+		if message == "" {
+			message = result.Detail
+		}
+		if message == "" {
+			message = "success=false"
+		}
+		// Confirmations signed by a removed authenticator are refused with a message about it
+		if strings.Contains(strings.ToLower(message), "authenticator") {
+			return nil, fmt.Errorf("%w: %s", ErrAuthenticatorRevoked, message)
+		}
+		return nil, fmt.Errorf("failed to get confirmations: %s", message)
+	}
+
+	if result.Conf == nil {
+		result.Conf = []*Confirmation{}
+	}
+	return result.Conf, nil
 }
 
 // AcceptConfirmation accepts a confirmation
 func (c *Client) AcceptConfirmation(account *manifest.SteamGuardAccount, conf *Confirmation) error {
-	return c.respondToConfirmation(account, conf, "allow")
+	if c.Approver != nil {
+		if err := c.Approver.CheckAccept(account, conf); err != nil {
+			return err
+		}
+	}
+
+	if err := c.respondToConfirmation(account, conf, "allow"); err != nil {
+		return err
+	}
+
+	if c.Approver != nil && !c.DryRun {
+		if err := c.Approver.Accepted(account, conf); err != nil {
+			return fmt.Errorf("confirmation accepted, but %w", err)
+		}
+	}
+	return nil
 }
 
 // RejectConfirmation rejects a confirmation
@@ -111,37 +141,29 @@ func (c *Client) RejectConfirmation(account *manifest.SteamGuardAccount, conf *C
 
 // respondToConfirmation sends a response to a confirmation
 func (c *Client) respondToConfirmation(account *manifest.SteamGuardAccount, conf *Confirmation, op string) error {
-	timestamp := time.Now().Unix()
-	
-	// Generate hash for specific operation
-	hashDetails, err := account.GenerateConfirmationHash("details"+conf.ID, timestamp)
-	if err != nil {
-		return fmt.Errorf("failed to generate hash: %w", err)
+	if err := account.CheckEnrolled(); err != nil {
+		return err
+	}
+	if c.DryRun {
+		return nil
 	}
 
-	deviceID := account.GetDeviceID()
-	steamID := account.Session.SteamID
-
-	// Build parameters
-	params := url.Values{}
+	// The hash tag is the operation itself
+	params, err := confirmationParams(account, op)
+	if err != nil {
+		return err
+	}
 	params.Set("op", op)
-	params.Set("p", deviceID)
-	params.Set("a", steamID)
-	params.Set("k", hashDetails)
-	params.Set("t", fmt.Sprintf("%d", timestamp))
-	params.Set("m", "android")
-	params.Set("tag", "conf")
 	params.Set("cid", conf.ID)
-	params.Set("ck", conf.Key)
+	params.Set("ck", conf.Nonce)
 
-	confURL := fmt.Sprintf("%s/mobileconf/ajaxop", steamLoginBase)
+	confURL := fmt.Sprintf("%s/mobileconf/ajaxop?%s", steamCommunityBase, params.Encode())
 
-	req, err := http.NewRequest("POST", confURL, strings.NewReader(params.Encode()))
+	req, err := http.NewRequest("GET", confURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	c.addSessionCookies(req, account)
 
 	resp, err := c.httpClient.Do(req)
@@ -157,13 +179,17 @@ func (c *Client) respondToConfirmation(account *manifest.SteamGuardAccount, conf
 
 	// Parse JSON response
 	var result struct {
-		Success bool `json:"success"`
+		Success  bool `json:"success"`
+		NeedAuth bool `json:"needauth"`
 	}
-	
+
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	if result.NeedAuth {
+		return ErrNeedAuth
+	}
 	if !result.Success {
 		return fmt.Errorf("operation was not successful")
 	}
@@ -171,6 +197,77 @@ func (c *Client) respondToConfirmation(account *manifest.SteamGuardAccount, conf
 	return nil
 }
 
+// confirmationParams builds the signed query parameters of mobileconf requests
+func confirmationParams(account *manifest.SteamGuardAccount, tag string) (url.Values, error) {
+	timestamp := time.Now().Unix()
+
+	hash, err := account.GenerateConfirmationHash(tag, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate confirmation hash: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("p", account.GetDeviceID())
+	params.Set("a", account.Session.SteamID)
+	params.Set("k", hash)
+	params.Set("t", fmt.Sprintf("%d", timestamp))
+	params.Set("m", "react")
+	params.Set("tag", tag)
+	return params, nil
+}
+
+// callService calls a Steam Web API service method and decodes its "response" object
+func (c *Client) callService(method, service, accessToken string, params url.Values, out interface{}) error {
+	serviceURL := fmt.Sprintf("%s/%s/v1/", steamAPIBase, service)
+
+	var body io.Reader
+	if method == http.MethodGet {
+		serviceURL += "?" + params.Encode()
+	} else {
+		body = strings.NewReader(params.Encode())
+	}
+	if accessToken != "" {
+		sep := "?"
+		if strings.Contains(serviceURL, "?") {
+			sep = "&"
+		}
+		serviceURL += sep + "access_token=" + url.QueryEscape(accessToken)
+	}
+
+	req, err := http.NewRequest(method, serviceURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s failed: %d - %s", service, resp.StatusCode, string(data))
+	}
+
+	// Steam reports most failures through the x-eresult header
+	if eresult := resp.Header.Get("X-Eresult"); eresult != "" && eresult != "1" {
+		return fmt.Errorf("%s failed: eresult %s", service, eresult)
+	}
+
+	wrapper := struct {
+		Response interface{} `json:"response"`
+	}{Response: out}
+	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
 // addSessionCookies adds session cookies to the request
 func (c *Client) addSessionCookies(req *http.Request, account *manifest.SteamGuardAccount) {
 	if account.Session.SessionID != "" {
@@ -185,4 +282,7 @@ func (c *Client) addSessionCookies(req *http.Request, account *manifest.SteamGua
 			Value: account.Session.SteamLoginSecure,
 		})
 	}
+	// Mobile confirmation pages are only served to the mobile app
+	req.AddCookie(&http.Cookie{Name: "mobileClient", Value: "android"})
+	req.AddCookie(&http.Cookie{Name: "mobileClientVersion", Value: "777777 3.6.4"})
 }
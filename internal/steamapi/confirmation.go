@@ -0,0 +1,165 @@
+package steamapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+// ErrNeedAuth is returned when Steam rejects the stored session
+var ErrNeedAuth = errors.New("steam session has expired, login again")
+
+// ErrAuthenticatorRevoked is returned when Steam no longer accepts the
+// authenticator, e.g. after it was removed with the revocation code
+var ErrAuthenticatorRevoked = errors.New("steam rejected the authenticator, it may have been revoked")
+
+// ErrApprovalRequired is returned when accepting needs a second operator's approval
+var ErrApprovalRequired = errors.New("confirmation needs approval by a second operator")
+
+// ErrHighRisk is returned when a high-risk confirmation would be accepted
+// automatically or together with others
+var ErrHighRisk = errors.New("high-risk confirmation must be accepted on its own")
+
+// Approver guards accepting confirmations, e.g. with two-person approval
+type Approver interface {
+	// CheckAccept returns an error if the confirmation may not be accepted yet
+	CheckAccept(account *manifest.SteamGuardAccount, conf *Confirmation) error
+	// Accepted is called after the confirmation was accepted; an error means
+	// the accept couldn't be recorded
+	Accepted(account *manifest.SteamGuardAccount, conf *Confirmation) error
+}
+
+// Observer watches the confirmations a client fetches, e.g. to send notifications.
+// It may be called from several goroutines at once.
+type Observer interface {
+	// ConfirmationsFetched is called with the confirmations of the account, or
+	// the error that kept them from being fetched
+	ConfirmationsFetched(account *manifest.SteamGuardAccount, confs []*Confirmation, err error)
+}
+
+// ConfirmationType type of a mobile confirmation
+type ConfirmationType int
+
+const (
+	ConfTypeGeneric           ConfirmationType = 1
+	ConfTypeTrade             ConfirmationType = 2
+	ConfTypeMarketListing     ConfirmationType = 3
+	ConfTypeFeatureOptOut     ConfirmationType = 4
+	ConfTypePhoneNumberChange ConfirmationType = 5
+	ConfTypeAccountRecovery   ConfirmationType = 6
+	ConfTypeAPIKey            ConfirmationType = 9
+	ConfTypeJoinSteamFamily   ConfirmationType = 11
+)
+
+// confTypeNames short names of confirmation types
+var confTypeNames = map[ConfirmationType]string{
+	ConfTypeGeneric:           "generic",
+	ConfTypeTrade:             "trade",
+	ConfTypeMarketListing:     "market",
+	ConfTypeFeatureOptOut:     "featureoptout",
+	ConfTypePhoneNumberChange: "phone",
+	ConfTypeAccountRecovery:   "recovery",
+	ConfTypeAPIKey:            "apikey",
+	ConfTypeJoinSteamFamily:   "family",
+}
+
+// Risk how much harm accepting a confirmation can do
+type Risk int
+
+const (
+	// RiskLow selling items on the market
+	RiskLow Risk = iota
+	// RiskMedium trades
+	RiskMedium
+	// RiskHigh confirmations that can hand over the account, like Web API
+	// keys, phone number changes and account recovery
+	RiskHigh
+)
+
+// riskNames names of risk levels
+var riskNames = map[Risk]string{
+	RiskLow:    "low",
+	RiskMedium: "medium",
+	RiskHigh:   "high",
+}
+
+// String returns the name of the risk level
+func (r Risk) String() string {
+	if name, ok := riskNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(r))
+}
+
+// confTypeRisks risk of the types that aren't high-risk
+var confTypeRisks = map[ConfirmationType]Risk{
+	ConfTypeTrade:         RiskMedium,
+	ConfTypeMarketListing: RiskLow,
+}
+
+// String returns the short name of the type
+func (t ConfirmationType) String() string {
+	if name, ok := confTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
+// Known checks if the type is one of the known confirmation types
+func (t ConfirmationType) Known() bool {
+	_, ok := confTypeNames[t]
+	return ok
+}
+
+// Risk returns the risk of accepting confirmations of the type. Unknown
+// types are high-risk, since Steam may add new sensitive ones any time.
+func (t ConfirmationType) Risk() Risk {
+	if risk, ok := confTypeRisks[t]; ok {
+		return risk
+	}
+	return RiskHigh
+}
+
+// CheckRisk returns ErrHighRisk for high-risk confirmations
+func CheckRisk(conf *Confirmation) error {
+	if conf.Type.Risk() < RiskHigh {
+		return nil
+	}
+	return fmt.Errorf("%w: %s confirmation %s", ErrHighRisk, conf.Type, conf.ID)
+}
+
+// Confirmation represents a trade/market confirmation as returned by mobileconf/getlist
+type Confirmation struct {
+	ID           string           `json:"id"`
+	Nonce        string           `json:"nonce"`
+	CreatorID    string           `json:"creator_id"`
+	Type         ConfirmationType `json:"type"`
+	TypeName     string           `json:"type_name"`
+	Headline     string           `json:"headline"`
+	Summary      []string         `json:"summary"`
+	Icon         string           `json:"icon"`
+	Multi        bool             `json:"multi"`
+	CreationTime int64            `json:"creation_time"`
+
+	// Offer contents of the trade offer, set by AttachTradeOffers
+	Offer *TradeOffer `json:"offer,omitempty"`
+}
+
+// Description returns a one-line description of the confirmation
+func (c *Confirmation) Description() string {
+	parts := []string{c.Headline}
+	for _, line := range c.Summary {
+		if line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " - ")
+}
+
+// Created returns the creation time of the confirmation
+func (c *Confirmation) Created() time.Time {
+	return time.Unix(c.CreationTime, 0)
+}
@@ -0,0 +1,276 @@
+package steamapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+// steamID64Base is added to a 32-bit account ID to get the SteamID64
+const steamID64Base = 76561197960265728
+
+// ConfirmationDetails structured content of the mobileconf details page
+type ConfirmationDetails struct {
+	ConfirmationID string        `json:"confirmation_id"`
+	Partner        *TradePartner `json:"partner,omitempty"`
+	Given          []TradeItem   `json:"given,omitempty"`
+	Received       []TradeItem   `json:"received,omitempty"`
+	Listing        *ListingPrice `json:"listing,omitempty"`
+	Text           []string      `json:"text,omitempty"`
+}
+
+// TradePartner the other side of a trade
+type TradePartner struct {
+	Persona string `json:"persona"`
+	SteamID string `json:"steamid,omitempty"`
+}
+
+// TradeItem an item of a trade
+type TradeItem struct {
+	AppID      int    `json:"appid"`
+	ContextID  string `json:"contextid,omitempty"`
+	AssetID    string `json:"assetid,omitempty"`
+	ClassID    string `json:"classid"`
+	InstanceID string `json:"instanceid,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Amount     int    `json:"amount,omitempty"`
+}
+
+// ListingPrice item and price of a market listing
+type ListingPrice struct {
+	ItemName   string `json:"item_name,omitempty"`
+	AppID      int    `json:"appid,omitempty"`
+	BuyerPays  string `json:"buyer_pays,omitempty"`
+	YouReceive string `json:"you_receive,omitempty"`
+}
+
+// GetConfirmationDetails fetches and parses the details page of a confirmation
+func (c *Client) GetConfirmationDetails(account *manifest.SteamGuardAccount, conf *Confirmation) (*ConfirmationDetails, error) {
+	if err := account.CheckEnrolled(); err != nil {
+		return nil, err
+	}
+
+	params, err := confirmationParams(account, "details"+conf.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	confURL := fmt.Sprintf("%s/mobileconf/details/%s?%s", steamCommunityBase, conf.ID, params.Encode())
+
+	req, err := http.NewRequest("GET", confURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.addSessionCookies(req, account)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get confirmation details: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Success  bool   `json:"success"`
+		NeedAuth bool   `json:"needauth"`
+		HTML     string `json:"html"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse confirmation details: %w", err)
+	}
+
+	if result.NeedAuth {
+		return nil, ErrNeedAuth
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("failed to get confirmation details: success=false")
+	}
+
+	details := ParseConfirmationDetails(result.HTML)
+	details.ConfirmationID = conf.ID
+	return details, nil
+}
+
+var (
+	tagPattern        = regexp.MustCompile(`(?s)<[^>]*>`)
+	blockTagPattern   = regexp.MustCompile(`(?is)<(br|/div|/p|/li|/h[1-6]|/tr)[^>]*>`)
+	scriptPattern     = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	economyItemRegexp = regexp.MustCompile(`data-economy-item="(?:classinfo/)?(\d+)/(\d+)(?:/(\d+))?"`)
+	itemTitlePattern  = regexp.MustCompile(`(?:title|alt)="([^"]+)"`)
+	itemAmountPattern = regexp.MustCompile(`(?s)class="item_currency_amount"[^>]*>\s*([\d,]+)`)
+	miniprofileRegexp = regexp.MustCompile(`data-miniprofile="(\d+)"`)
+	personaPattern    = regexp.MustCompile(`(?is)class="[^"]*(?:trade_partner_persona|tradeoffer_partner_name|playerName)[^"]*"[^>]*>(.*?)</`)
+	listingLinkRegexp = regexp.MustCompile(`/market/listings/(\d+)/`)
+	listingNameRegexp = regexp.MustCompile(`(?is)class="[^"]*(?:market_listing_item_name|mobileconf_listing_item_name)[^"]*"[^>]*>(.*?)</`)
+	pricePattern      = regexp.MustCompile(`[^\s:]*\d[\d.,\s]*[^\s]*`)
+)
+
+// ParseConfirmationDetails extracts partner, items and listing price from the details HTML.
+// Anything that can't be recognized is kept as plain text lines.
+func ParseConfirmationDetails(page string) *ConfirmationDetails {
+	details := &ConfirmationDetails{}
+	page = scriptPattern.ReplaceAllString(page, "")
+
+	// The primary side is ours, the secondary side is the partner's
+	if primary, secondary, ok := splitTradeSides(page); ok {
+		details.Given = parseTradeItems(primary)
+		details.Received = parseTradeItems(secondary)
+	}
+
+	if match := miniprofileRegexp.FindStringSubmatch(page); match != nil {
+		accountID, _ := strconv.ParseUint(match[1], 10, 32)
+		details.Partner = &TradePartner{SteamID: strconv.FormatUint(accountID+steamID64Base, 10)}
+	}
+	if persona := findPersona(page); persona != "" {
+		if details.Partner == nil {
+			details.Partner = &TradePartner{}
+		}
+		details.Partner.Persona = persona
+	}
+
+	details.Text = htmlText(page)
+	if details.Given == nil && details.Received == nil {
+		details.Listing = parseListingPrice(page, details.Text)
+	}
+
+	return details
+}
+
+// splitTradeSides returns the HTML of the primary and secondary item lists
+func splitTradeSides(page string) (string, string, bool) {
+	primary := strings.Index(page, "tradeoffer_items primary")
+	secondary := strings.Index(page, "tradeoffer_items secondary")
+	if primary < 0 || secondary < 0 {
+		return "", "", false
+	}
+	if primary < secondary {
+		return page[primary:secondary], page[secondary:], true
+	}
+	return page[primary:], page[secondary:primary], true
+}
+
+// parseTradeItems extracts the items of one side of a trade
+func parseTradeItems(section string) []TradeItem {
+	var items []TradeItem
+	locations := economyItemRegexp.FindAllStringSubmatchIndex(section, -1)
+	for i, loc := range locations {
+		end := len(section)
+		if i+1 < len(locations) {
+			end = locations[i+1][0]
+		}
+		chunk := section[loc[0]:end]
+
+		appID, _ := strconv.Atoi(section[loc[2]:loc[3]])
+		item := TradeItem{
+			AppID:   appID,
+			ClassID: section[loc[4]:loc[5]],
+		}
+		if loc[6] >= 0 {
+			item.InstanceID = section[loc[6]:loc[7]]
+		}
+		if match := itemTitlePattern.FindStringSubmatch(chunk); match != nil {
+			item.Name = html.UnescapeString(match[1])
+		}
+		if match := itemAmountPattern.FindStringSubmatch(chunk); match != nil {
+			item.Amount, _ = strconv.Atoi(strings.ReplaceAll(match[1], ",", ""))
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// findPersona finds the partner's persona name
+func findPersona(page string) string {
+	if match := personaPattern.FindStringSubmatch(page); match != nil {
+		if persona := cleanText(match[1]); persona != "" {
+			return persona
+		}
+	}
+
+	// Fall back to the line following "You are trading with"
+	lines := htmlText(page)
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		if idx := strings.Index(lower, "trading with"); idx >= 0 {
+			if rest := strings.TrimSpace(strings.TrimSuffix(line[idx+len("trading with"):], ".")); rest != "" {
+				return rest
+			}
+			if i+1 < len(lines) {
+				return lines[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// parseListingPrice finds the listing item name and prices
+func parseListingPrice(page string, lines []string) *ListingPrice {
+	listing := &ListingPrice{
+		BuyerPays:  priceAfter(lines, "buyer pays"),
+		YouReceive: priceAfter(lines, "you receive"),
+	}
+	if match := listingNameRegexp.FindStringSubmatch(page); match != nil {
+		listing.ItemName = cleanText(match[1])
+	}
+	if match := economyItemRegexp.FindStringSubmatch(page); match != nil {
+		listing.AppID, _ = strconv.Atoi(match[1])
+	} else if match := listingLinkRegexp.FindStringSubmatch(page); match != nil {
+		listing.AppID, _ = strconv.Atoi(match[1])
+	}
+
+	if listing.BuyerPays == "" && listing.YouReceive == "" {
+		return nil
+	}
+	return listing
+}
+
+// priceAfter returns the price following a label, on the same or the next line
+func priceAfter(lines []string, label string) string {
+	for i, line := range lines {
+		idx := strings.Index(strings.ToLower(line), label)
+		if idx < 0 {
+			continue
+		}
+		if price := pricePattern.FindString(line[idx+len(label):]); price != "" {
+			return strings.TrimSpace(price)
+		}
+		if i+1 < len(lines) {
+			if price := pricePattern.FindString(lines[i+1]); price != "" {
+				return strings.TrimSpace(price)
+			}
+		}
+	}
+	return ""
+}
+
+// htmlText converts HTML to non-empty text lines
+func htmlText(page string) []string {
+	page = blockTagPattern.ReplaceAllString(page, "\n")
+	page = tagPattern.ReplaceAllString(page, "")
+
+	var lines []string
+	for _, line := range strings.Split(html.UnescapeString(page), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// cleanText strips tags and collapses whitespace
+func cleanText(fragment string) string {
+	fragment = tagPattern.ReplaceAllString(fragment, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(fragment)), " ")
+}
@@ -0,0 +1,95 @@
+package steamapi
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfirmationFilter selects confirmations. Empty fields match everything,
+// a confirmation must match every non-empty field.
+type ConfirmationFilter struct {
+	// IDs confirmation IDs or creator IDs (trade offer / listing IDs)
+	IDs       []string
+	Types     []ConfirmationType
+	Creators  []string
+	Match     *regexp.Regexp
+	OlderThan time.Duration
+}
+
+// ParseConfirmationType parses a type name (trade, market, apikey, ...) or number
+func ParseConfirmationType(name string) (ConfirmationType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for confType, typeName := range confTypeNames {
+		if typeName == name {
+			return confType, nil
+		}
+	}
+
+	if number, err := strconv.Atoi(name); err == nil {
+		return ConfirmationType(number), nil
+	}
+
+	names := make([]string, 0, len(confTypeNames))
+	for confType := ConfTypeGeneric; confType <= ConfTypeJoinSteamFamily; confType++ {
+		if typeName, ok := confTypeNames[confType]; ok {
+			names = append(names, typeName)
+		}
+	}
+	return 0, fmt.Errorf("unknown confirmation type %q (known: %s)", name, strings.Join(names, ", "))
+}
+
+// IsEmpty checks if the filter passes every confirmation
+func (f ConfirmationFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && len(f.Types) == 0 && len(f.Creators) == 0 && f.Match == nil && f.OlderThan <= 0
+}
+
+// Matches checks if a confirmation passes the filter
+func (f ConfirmationFilter) Matches(conf *Confirmation) bool {
+	if len(f.IDs) > 0 && !slices.Contains(f.IDs, conf.ID) && !slices.Contains(f.IDs, conf.CreatorID) {
+		return false
+	}
+	if len(f.Creators) > 0 && !slices.Contains(f.Creators, conf.CreatorID) {
+		return false
+	}
+
+	if len(f.Types) > 0 {
+		found := false
+		for _, confType := range f.Types {
+			if conf.Type == confType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.Match != nil {
+		text := conf.Headline + "\n" + strings.Join(conf.Summary, "\n")
+		if !f.Match.MatchString(text) {
+			return false
+		}
+	}
+
+	if f.OlderThan > 0 && time.Since(conf.Created()) < f.OlderThan {
+		return false
+	}
+
+	return true
+}
+
+// Apply returns the confirmations passing the filter
+func (f ConfirmationFilter) Apply(confs []*Confirmation) []*Confirmation {
+	var matched []*Confirmation
+	for _, conf := range confs {
+		if f.Matches(conf) {
+			matched = append(matched, conf)
+		}
+	}
+	return matched
+}
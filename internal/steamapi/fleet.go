@@ -0,0 +1,120 @@
+package steamapi
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+// AccountConfirmations the confirmations of one account fetched by FetchConfirmations
+type AccountConfirmations struct {
+	Account       *manifest.SteamGuardAccount
+	Confirmations []*Confirmation
+	// Err failure to get the confirmations
+	Err error
+	// OfferErr failure to attach trade offers, the confirmations are still usable
+	OfferErr error
+}
+
+// FetchOptions how FetchConfirmations works
+type FetchOptions struct {
+	// Parallel accounts fetched at the same time, 1 when zero
+	Parallel int
+	// Offers attaches trade offers to trade confirmations
+	Offers bool
+}
+
+// FetchConfirmations gets the confirmations of many accounts on a pool of
+// workers. The results are in the order of the accounts.
+func (c *Client) FetchConfirmations(accounts []*manifest.SteamGuardAccount, opts FetchOptions) []AccountConfirmations {
+	results := make([]AccountConfirmations, len(accounts))
+	ForEachAccount(accounts, opts.Parallel, func(i int, account *manifest.SteamGuardAccount) {
+		result := AccountConfirmations{Account: account}
+		result.Confirmations, result.Err = c.GetConfirmations(account)
+		if result.Err == nil && opts.Offers {
+			result.OfferErr = c.AttachTradeOffers(account, result.Confirmations)
+		}
+		results[i] = result
+	})
+	return results
+}
+
+// ForEachAccount calls fn for every account, at most parallel at a time,
+// and waits until all calls have returned
+func ForEachAccount(accounts []*manifest.SteamGuardAccount, parallel int, fn func(i int, account *manifest.SteamGuardAccount)) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	parallel = min(parallel, len(accounts))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i, accounts[i])
+			}
+		}()
+	}
+	for i := range accounts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// SetRateLimit spaces out the client's requests to Steam so there are at
+// most perSecond of them, across all goroutines. Zero removes the limit.
+func (c *Client) SetRateLimit(perSecond float64) {
+	base := c.httpClient.Transport
+	if limited, ok := base.(*rateLimitedTransport); ok {
+		base = limited.base
+	}
+	if perSecond <= 0 {
+		c.httpClient.Transport = base
+		return
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.httpClient.Transport = &rateLimitedTransport{
+		base:     base,
+		interval: time.Duration(float64(time.Second) / perSecond),
+	}
+}
+
+// rateLimitedTransport lets requests through one interval apart
+type rateLimitedTransport struct {
+	base     http.RoundTripper
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	now := time.Now()
+	slot := t.next
+	if slot.Before(now) {
+		slot = now
+	}
+	t.next = slot.Add(t.interval)
+	t.mu.Unlock()
+
+	if wait := time.Until(slot); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	return t.base.RoundTrip(req)
+}
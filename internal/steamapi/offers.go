@@ -0,0 +1,297 @@
+package steamapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+// ErrConfirmationTimeout is returned when an expected confirmation doesn't show up in time
+var ErrConfirmationTimeout = errors.New("timed out waiting for the confirmation")
+
+// TradeOffersQuery selects the offers returned by GetTradeOffers
+type TradeOffersQuery struct {
+	Sent     bool
+	Received bool
+	// ActiveOnly returns only offers that are still active
+	ActiveOnly bool
+}
+
+// GetTradeOffers fetches sent and received trade offers with item names
+func (c *Client) GetTradeOffers(account *manifest.SteamGuardAccount, query TradeOffersQuery) (sent, received []*TradeOffer, err error) {
+	params := url.Values{}
+	params.Set("get_sent_offers", boolParam(query.Sent))
+	params.Set("get_received_offers", boolParam(query.Received))
+	params.Set("active_only", boolParam(query.ActiveOnly))
+	params.Set("get_descriptions", "1")
+	params.Set("language", "english")
+	if query.ActiveOnly {
+		// Without a cutoff, offers that changed state recently are returned as well
+		params.Set("time_historical_cutoff", strconv.FormatInt(time.Now().Unix(), 10))
+	}
+
+	accessToken, err := c.webAPIAuth(account, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result struct {
+		Sent         []economyOffer       `json:"trade_offers_sent"`
+		Received     []economyOffer       `json:"trade_offers_received"`
+		Descriptions []economyDescription `json:"descriptions"`
+	}
+	if err := c.callService(http.MethodGet, "IEconService/GetTradeOffers", accessToken, params, &result); err != nil {
+		return nil, nil, err
+	}
+
+	for _, offer := range result.Sent {
+		sent = append(sent, offer.toTradeOffer(result.Descriptions))
+	}
+	for _, offer := range result.Received {
+		received = append(received, offer.toTradeOffer(result.Descriptions))
+	}
+	return sent, received, nil
+}
+
+// TradeOfferAcceptance result of accepting a trade offer
+type TradeOfferAcceptance struct {
+	// TradeID ID of the trade, empty while it waits for a confirmation
+	TradeID                 string `json:"tradeid,omitempty"`
+	NeedsMobileConfirmation bool   `json:"needs_mobile_confirmation"`
+	NeedsEmailConfirmation  bool   `json:"needs_email_confirmation"`
+}
+
+// AcceptTradeOffer accepts an incoming trade offer. When the acceptance
+// needs a mobile confirmation, it has to be accepted separately.
+func (c *Client) AcceptTradeOffer(account *manifest.SteamGuardAccount, offer *TradeOffer) (*TradeOfferAcceptance, error) {
+	if offer.IsOurOffer {
+		return nil, fmt.Errorf("trade offer %s was sent by this account, it can only be cancelled", offer.ID)
+	}
+	if offer.State != TradeOfferStateActive {
+		return nil, fmt.Errorf("trade offer %s is %s", offer.ID, offer.State)
+	}
+
+	form := url.Values{}
+	form.Set("serverid", "1")
+	form.Set("tradeofferid", offer.ID)
+	form.Set("partner", offer.PartnerSteamID)
+	form.Set("captcha", "")
+
+	var result struct {
+		TradeOfferAcceptance
+		Error string `json:"strError"`
+	}
+	path := fmt.Sprintf("/tradeoffer/%s/accept", offer.ID)
+	referer := fmt.Sprintf("%s/tradeoffer/%s/", steamCommunityBase, offer.ID)
+	if err := c.communityPost(account, path, referer, form, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("failed to accept trade offer %s: %s", offer.ID, result.Error)
+	}
+	return &result.TradeOfferAcceptance, nil
+}
+
+// DeclineTradeOffer declines an incoming trade offer
+func (c *Client) DeclineTradeOffer(account *manifest.SteamGuardAccount, offerID string) error {
+	return c.tradeOfferAction(account, "IEconService/DeclineTradeOffer", offerID)
+}
+
+// CancelTradeOffer cancels a trade offer sent by the account
+func (c *Client) CancelTradeOffer(account *manifest.SteamGuardAccount, offerID string) error {
+	return c.tradeOfferAction(account, "IEconService/CancelTradeOffer", offerID)
+}
+
+// tradeOfferAction calls an IEconService method taking only the offer ID
+func (c *Client) tradeOfferAction(account *manifest.SteamGuardAccount, service, offerID string) error {
+	params := url.Values{}
+	params.Set("tradeofferid", offerID)
+
+	accessToken, err := c.webAPIAuth(account, params)
+	if err != nil {
+		return err
+	}
+
+	var result struct{}
+	return c.callService(http.MethodPost, service, accessToken, params, &result)
+}
+
+// TradeStatus status of a trade
+type TradeStatus int
+
+const (
+	TradeStatusInit                    TradeStatus = 0
+	TradeStatusPreCommitted            TradeStatus = 1
+	TradeStatusCommitted               TradeStatus = 2
+	TradeStatusComplete                TradeStatus = 3
+	TradeStatusFailed                  TradeStatus = 4
+	TradeStatusPartialSupportRollback  TradeStatus = 5
+	TradeStatusFullSupportRollback     TradeStatus = 6
+	TradeStatusSupportRollbackSelected TradeStatus = 7
+	TradeStatusRollbackFailed          TradeStatus = 8
+	TradeStatusRollbackAbandoned       TradeStatus = 9
+	TradeStatusInEscrow                TradeStatus = 10
+	TradeStatusEscrowRollback          TradeStatus = 11
+)
+
+// tradeStatusNames names of trade statuses
+var tradeStatusNames = map[TradeStatus]string{
+	TradeStatusInit:                    "init",
+	TradeStatusPreCommitted:            "pre-committed",
+	TradeStatusCommitted:               "committed",
+	TradeStatusComplete:                "complete",
+	TradeStatusFailed:                  "failed",
+	TradeStatusPartialSupportRollback:  "partial support rollback",
+	TradeStatusFullSupportRollback:     "full support rollback",
+	TradeStatusSupportRollbackSelected: "support rollback (selected items)",
+	TradeStatusRollbackFailed:          "rollback failed",
+	TradeStatusRollbackAbandoned:       "rollback abandoned",
+	TradeStatusInEscrow:                "in escrow",
+	TradeStatusEscrowRollback:          "escrow rollback",
+}
+
+// String returns the name of the status
+func (s TradeStatus) String() string {
+	if name, ok := tradeStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// Trade a trade resulting from an accepted offer
+type Trade struct {
+	ID             string      `json:"tradeid"`
+	PartnerSteamID string      `json:"partner_steamid"`
+	Status         TradeStatus `json:"status"`
+	Created        time.Time   `json:"created"`
+	Given          []TradeItem `json:"given"`
+	Received       []TradeItem `json:"received"`
+}
+
+// GetTradeStatus fetches the status of a trade
+func (c *Client) GetTradeStatus(account *manifest.SteamGuardAccount, tradeID string) (*Trade, error) {
+	params := url.Values{}
+	params.Set("tradeid", tradeID)
+	params.Set("get_descriptions", "1")
+	params.Set("language", "english")
+
+	accessToken, err := c.webAPIAuth(account, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Trades []struct {
+			TradeID        string        `json:"tradeid"`
+			SteamIDOther   string        `json:"steamid_other"`
+			TimeInit       int64         `json:"time_init"`
+			Status         int           `json:"status"`
+			AssetsGiven    []economyItem `json:"assets_given"`
+			AssetsReceived []economyItem `json:"assets_received"`
+		} `json:"trades"`
+		Descriptions []economyDescription `json:"descriptions"`
+	}
+	if err := c.callService(http.MethodGet, "IEconService/GetTradeStatus", accessToken, params, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Trades) == 0 {
+		return nil, fmt.Errorf("trade %s not found", tradeID)
+	}
+
+	names := descriptionNames(result.Descriptions)
+	trade := result.Trades[0]
+	return &Trade{
+		ID:             trade.TradeID,
+		PartnerSteamID: trade.SteamIDOther,
+		Status:         TradeStatus(trade.Status),
+		Created:        time.Unix(trade.TimeInit, 0),
+		Given:          convertItems(trade.AssetsGiven, names),
+		Received:       convertItems(trade.AssetsReceived, names),
+	}, nil
+}
+
+// WaitForConfirmation polls the confirmations until one with the creator ID
+// (trade offer or listing ID) shows up. Failed polls are retried until the
+// timeout, except when the session or authenticator needs attention.
+// Returns ErrConfirmationTimeout, wrapping the last failure if any, when
+// none showed up within the timeout.
+func (c *Client) WaitForConfirmation(account *manifest.SteamGuardAccount, creatorID string, timeout, interval time.Duration) (*Confirmation, error) {
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for {
+		confirmations, err := c.GetConfirmations(account)
+		switch {
+		case errors.Is(err, ErrNeedAuth), errors.Is(err, ErrAuthenticatorRevoked), errors.Is(err, manifest.ErrNotFullyEnrolled):
+			return nil, err
+		case err != nil:
+			lastErr = err
+		default:
+			lastErr = nil
+			for _, conf := range confirmations {
+				if conf.CreatorID == creatorID {
+					return conf, nil
+				}
+			}
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w for %s, last poll failed: %w", ErrConfirmationTimeout, creatorID, lastErr)
+			}
+			return nil, fmt.Errorf("%w for %s", ErrConfirmationTimeout, creatorID)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// communityPost posts a form to steamcommunity.com with the session cookies
+func (c *Client) communityPost(account *manifest.SteamGuardAccount, path, referer string, form url.Values, out interface{}) error {
+	if account.Session.SessionID == "" || account.Session.SteamLoginSecure == "" {
+		return fmt.Errorf("no web session for %s, log in again", account.AccountName)
+	}
+	form.Set("sessionid", account.Session.SessionID)
+
+	req, err := http.NewRequest(http.MethodPost, steamCommunityBase+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", referer)
+	c.addSessionCookies(req, account)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrNeedAuth
+	}
+
+	// Errors come with status 500 and a strError field, let the caller report them
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s failed: %d - %s", path, resp.StatusCode, string(data))
+	}
+	return nil
+}
+
+// boolParam formats a boolean Web API parameter
+func boolParam(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,327 @@
+package steamapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+// TradeOfferState state of a trade offer
+type TradeOfferState int
+
+const (
+	TradeOfferStateInvalid                  TradeOfferState = 1
+	TradeOfferStateActive                   TradeOfferState = 2
+	TradeOfferStateAccepted                 TradeOfferState = 3
+	TradeOfferStateCountered                TradeOfferState = 4
+	TradeOfferStateExpired                  TradeOfferState = 5
+	TradeOfferStateCanceled                 TradeOfferState = 6
+	TradeOfferStateDeclined                 TradeOfferState = 7
+	TradeOfferStateInvalidItems             TradeOfferState = 8
+	TradeOfferStateCreatedNeedsConfirmation TradeOfferState = 9
+	TradeOfferStateCanceledBySecondFactor   TradeOfferState = 10
+	TradeOfferStateInEscrow                 TradeOfferState = 11
+)
+
+// tradeOfferStateNames names of trade offer states
+var tradeOfferStateNames = map[TradeOfferState]string{
+	TradeOfferStateInvalid:                  "invalid",
+	TradeOfferStateActive:                   "active",
+	TradeOfferStateAccepted:                 "accepted",
+	TradeOfferStateCountered:                "countered",
+	TradeOfferStateExpired:                  "expired",
+	TradeOfferStateCanceled:                 "canceled",
+	TradeOfferStateDeclined:                 "declined",
+	TradeOfferStateInvalidItems:             "invalid items",
+	TradeOfferStateCreatedNeedsConfirmation: "needs confirmation",
+	TradeOfferStateCanceledBySecondFactor:   "canceled by second factor",
+	TradeOfferStateInEscrow:                 "in escrow",
+}
+
+// String returns the name of the state
+func (s TradeOfferState) String() string {
+	if name, ok := tradeOfferStateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// TradeOffer contents of a trade offer as returned by IEconService
+type TradeOffer struct {
+	ID             string          `json:"id"`
+	PartnerSteamID string          `json:"partner_steamid"`
+	Message        string          `json:"message,omitempty"`
+	State          TradeOfferState `json:"state"`
+	IsOurOffer     bool            `json:"is_our_offer"`
+	// TradeID ID of the trade once the offer was accepted
+	TradeID        string      `json:"tradeid,omitempty"`
+	ItemsToGive    []TradeItem `json:"items_to_give"`
+	ItemsToReceive []TradeItem `json:"items_to_receive"`
+	Created        time.Time   `json:"created"`
+	Expires        time.Time   `json:"expires"`
+	// EscrowEnd end of the trade hold, zero when the trade isn't held
+	EscrowEnd *time.Time `json:"escrow_end,omitempty"`
+	// Hold trade hold durations with the partner, nil when unknown
+	Hold *TradeHold `json:"hold,omitempty"`
+}
+
+// TradeHold how long items of a trade would be held
+type TradeHold struct {
+	// Mine hold caused by our account
+	Mine time.Duration `json:"mine"`
+	// Theirs hold caused by the partner's account
+	Theirs time.Duration `json:"theirs"`
+	// Both hold of the trade, the longer of the two
+	Both time.Duration `json:"both"`
+}
+
+// Held checks if the trade would be held
+func (h *TradeHold) Held() bool {
+	return h.Both > 0
+}
+
+// Days returns the hold of the trade in whole days, rounded up
+func (h *TradeHold) Days() int {
+	return int((h.Both + 24*time.Hour - 1) / (24 * time.Hour))
+}
+
+// String describes the hold, e.g. "none" or "15 days"
+func (h *TradeHold) String() string {
+	if !h.Held() {
+		return "none"
+	}
+	if days := h.Days(); days != 1 {
+		return fmt.Sprintf("%d days", days)
+	}
+	return "1 day"
+}
+
+// economyItem an item as returned by IEconService
+type economyItem struct {
+	AppID      int    `json:"appid"`
+	ContextID  string `json:"contextid"`
+	AssetID    string `json:"assetid"`
+	ClassID    string `json:"classid"`
+	InstanceID string `json:"instanceid"`
+	Amount     string `json:"amount"`
+}
+
+// economyDescription item description as returned by IEconService
+type economyDescription struct {
+	AppID          int    `json:"appid"`
+	ClassID        string `json:"classid"`
+	InstanceID     string `json:"instanceid"`
+	Name           string `json:"name"`
+	MarketHashName string `json:"market_hash_name"`
+}
+
+// economyOffer a trade offer as returned by IEconService
+type economyOffer struct {
+	TradeOfferID   string        `json:"tradeofferid"`
+	AccountIDOther uint64        `json:"accountid_other"`
+	Message        string        `json:"message"`
+	ExpirationTime int64         `json:"expiration_time"`
+	State          int           `json:"trade_offer_state"`
+	ItemsToGive    []economyItem `json:"items_to_give"`
+	ItemsToReceive []economyItem `json:"items_to_receive"`
+	IsOurOffer     bool          `json:"is_our_offer"`
+	TimeCreated    int64         `json:"time_created"`
+	EscrowEndDate  int64         `json:"escrow_end_date"`
+	TradeID        string        `json:"tradeid"`
+}
+
+// toTradeOffer converts the API representation, naming items from descriptions
+func (o economyOffer) toTradeOffer(descriptions []economyDescription) *TradeOffer {
+	names := descriptionNames(descriptions)
+	convert := func(items []economyItem) []TradeItem {
+		return convertItems(items, names)
+	}
+
+	offer := &TradeOffer{
+		ID:             o.TradeOfferID,
+		PartnerSteamID: strconv.FormatUint(o.AccountIDOther+steamID64Base, 10),
+		Message:        o.Message,
+		State:          TradeOfferState(o.State),
+		IsOurOffer:     o.IsOurOffer,
+		TradeID:        o.TradeID,
+		ItemsToGive:    convert(o.ItemsToGive),
+		ItemsToReceive: convert(o.ItemsToReceive),
+		Created:        time.Unix(o.TimeCreated, 0),
+		Expires:        time.Unix(o.ExpirationTime, 0),
+	}
+	if o.EscrowEndDate > 0 {
+		escrowEnd := time.Unix(o.EscrowEndDate, 0)
+		offer.EscrowEnd = &escrowEnd
+	}
+	return offer
+}
+
+// descriptionNames maps "appid/classid/instanceid" to item names
+func descriptionNames(descriptions []economyDescription) map[string]string {
+	names := make(map[string]string, len(descriptions))
+	for _, d := range descriptions {
+		name := d.MarketHashName
+		if name == "" {
+			name = d.Name
+		}
+		names[fmt.Sprintf("%d/%s/%s", d.AppID, d.ClassID, d.InstanceID)] = name
+	}
+	return names
+}
+
+// convertItems converts API items, naming them from descriptions
+func convertItems(items []economyItem, names map[string]string) []TradeItem {
+	converted := make([]TradeItem, 0, len(items))
+	for _, item := range items {
+		amount, _ := strconv.Atoi(item.Amount)
+		converted = append(converted, TradeItem{
+			AppID:      item.AppID,
+			ContextID:  item.ContextID,
+			AssetID:    item.AssetID,
+			ClassID:    item.ClassID,
+			InstanceID: item.InstanceID,
+			Name:       names[fmt.Sprintf("%d/%s/%s", item.AppID, item.ClassID, item.InstanceID)],
+			Amount:     amount,
+		})
+	}
+	return converted
+}
+
+// GetTradeOffer fetches a trade offer with item names
+func (c *Client) GetTradeOffer(account *manifest.SteamGuardAccount, offerID string) (*TradeOffer, error) {
+	params := url.Values{}
+	params.Set("tradeofferid", offerID)
+	params.Set("get_descriptions", "1")
+	params.Set("language", "english")
+
+	accessToken, err := c.webAPIAuth(account, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Offer        *economyOffer        `json:"offer"`
+		Descriptions []economyDescription `json:"descriptions"`
+	}
+	if err := c.callService(http.MethodGet, "IEconService/GetTradeOffer", accessToken, params, &result); err != nil {
+		return nil, err
+	}
+	if result.Offer == nil {
+		return nil, fmt.Errorf("trade offer %s not found", offerID)
+	}
+
+	return result.Offer.toTradeOffer(result.Descriptions), nil
+}
+
+// GetTradeHoldDurations fetches how long a trade with the partner would be held
+func (c *Client) GetTradeHoldDurations(account *manifest.SteamGuardAccount, partnerSteamID string) (*TradeHold, error) {
+	params := url.Values{}
+	params.Set("steamid_target", partnerSteamID)
+
+	accessToken, err := c.webAPIAuth(account, params)
+	if err != nil {
+		return nil, err
+	}
+
+	type escrow struct {
+		Seconds int64 `json:"escrow_end_duration_seconds"`
+	}
+	var result struct {
+		MyEscrow    *escrow `json:"my_escrow"`
+		TheirEscrow *escrow `json:"their_escrow"`
+		BothEscrow  *escrow `json:"both_escrow"`
+	}
+	if err := c.callService(http.MethodGet, "IEconService/GetTradeHoldDurations", accessToken, params, &result); err != nil {
+		return nil, err
+	}
+	if result.BothEscrow == nil {
+		return nil, fmt.Errorf("no trade hold durations for %s", partnerSteamID)
+	}
+
+	hold := &TradeHold{Both: time.Duration(result.BothEscrow.Seconds) * time.Second}
+	if result.MyEscrow != nil {
+		hold.Mine = time.Duration(result.MyEscrow.Seconds) * time.Second
+	}
+	if result.TheirEscrow != nil {
+		hold.Theirs = time.Duration(result.TheirEscrow.Seconds) * time.Second
+	}
+	return hold, nil
+}
+
+// AttachTradeOffers fetches the offers of trade confirmations into Confirmation.Offer,
+// with the trade hold durations of the partner.
+// Returns the first error; the other confirmations are still attached.
+func (c *Client) AttachTradeOffers(account *manifest.SteamGuardAccount, confs []*Confirmation) error {
+	var firstErr error
+	for _, conf := range confs {
+		if conf.Type != ConfTypeTrade || conf.Offer != nil || conf.CreatorID == "" {
+			continue
+		}
+		offer, err := c.GetTradeOffer(account, conf.CreatorID)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to get trade offer %s: %w", conf.CreatorID, err)
+			}
+			continue
+		}
+		conf.Offer = offer
+
+		hold, err := c.GetTradeHoldDurations(account, offer.PartnerSteamID)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to get trade hold of offer %s: %w", conf.CreatorID, err)
+			}
+			continue
+		}
+		offer.Hold = hold
+	}
+	return firstErr
+}
+
+// webAPIAuth returns the access token of the session, or adds the account's
+// Web API key to the parameters when there is none
+func (c *Client) webAPIAuth(account *manifest.SteamGuardAccount, params url.Values) (string, error) {
+	if account.Session.AccessToken != "" && !account.Session.AccessTokenExpired() {
+		return account.Session.AccessToken, nil
+	}
+	if account.WebAPIKey != "" {
+		params.Set("key", account.WebAPIKey)
+		return "", nil
+	}
+	return "", fmt.Errorf("no valid access token or Web API key for %s", account.AccountName)
+}
+
+// ErrTradeHeld is returned for trades that would be held or whose hold is unknown
+var ErrTradeHeld = errors.New("trade hold")
+
+// CheckTradeHold returns an error wrapping ErrTradeHeld when a trade confirmation
+// would be held, or its hold couldn't be determined. Other confirmations pass.
+func CheckTradeHold(conf *Confirmation) error {
+	if conf.Type != ConfTypeTrade {
+		return nil
+	}
+	if conf.Offer == nil || conf.Offer.Hold == nil {
+		return fmt.Errorf("%w unknown for confirmation %s", ErrTradeHeld, conf.ID)
+	}
+	if conf.Offer.Hold.Held() {
+		return fmt.Errorf("%w of %s on confirmation %s", ErrTradeHeld, conf.Offer.Hold, conf.ID)
+	}
+	return nil
+}
+
+// CheckOfferHold returns an error wrapping ErrTradeHeld when accepting the
+// trade offer would hold its items, or its hold couldn't be determined
+func CheckOfferHold(offer *TradeOffer) error {
+	if offer.Hold == nil {
+		return fmt.Errorf("%w unknown for trade offer %s", ErrTradeHeld, offer.ID)
+	}
+	if offer.Hold.Held() {
+		return fmt.Errorf("%w of %s on trade offer %s", ErrTradeHeld, offer.Hold, offer.ID)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package steamapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/devhooly/steamguard-go/internal/manifest"
+)
+
+var (
+	// ErrPhoneRequired is returned when the account needs a phone number before enrollment
+	ErrPhoneRequired = errors.New("account requires a phone number")
+	// ErrAuthenticatorPresent is returned when the account already has an authenticator
+	ErrAuthenticatorPresent = errors.New("account already has an authenticator")
+	// ErrBadActivationCode is returned when the SMS or email activation code is wrong
+	ErrBadActivationCode = errors.New("invalid activation code")
+)
+
+// ActivationType tells where Steam sent the activation code
+type ActivationType int
+
+const (
+	ActivationSMS   ActivationType = 1
+	ActivationEmail ActivationType = 3
+)
+
+// AddAuthenticator starts authenticator enrollment and returns the new,
+// not yet finalized account together with the activation code channel
+func (c *Client) AddAuthenticator(session *manifest.SessionData, deviceID string) (*manifest.SteamGuardAccount, ActivationType, error) {
+	var result struct {
+		SharedSecret   string         `json:"shared_secret"`
+		SerialNumber   string         `json:"serial_number"`
+		RevocationCode string         `json:"revocation_code"`
+		URI            string         `json:"uri"`
+		ServerTime     string         `json:"server_time"`
+		AccountName    string         `json:"account_name"`
+		TokenGID       string         `json:"token_gid"`
+		IdentitySecret string         `json:"identity_secret"`
+		Secret1        string         `json:"secret_1"`
+		Status         int            `json:"status"`
+		ConfirmType    ActivationType `json:"confirm_type"`
+	}
+
+	params := url.Values{}
+	params.Set("steamid", session.SteamID)
+	params.Set("authenticator_type", "1")
+	params.Set("device_identifier", deviceID)
+	params.Set("sms_phone_id", "1")
+	params.Set("version", "2")
+	if err := c.callService(http.MethodPost, "ITwoFactorService/AddAuthenticator", session.AccessToken, params, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to add authenticator: %w", err)
+	}
+
+	switch result.Status {
+	case 1:
+	case 2:
+		return nil, 0, ErrPhoneRequired
+	case 29:
+		return nil, 0, ErrAuthenticatorPresent
+	default:
+		return nil, 0, fmt.Errorf("failed to add authenticator: status %d", result.Status)
+	}
+
+	serverTime, _ := strconv.ParseInt(result.ServerTime, 10, 64)
+	account := &manifest.SteamGuardAccount{
+		AccountName:    result.AccountName,
+		SharedSecret:   result.SharedSecret,
+		SerialNumber:   result.SerialNumber,
+		RevocationCode: result.RevocationCode,
+		URI:            result.URI,
+		ServerTime:     serverTime,
+		TokenGID:       result.TokenGID,
+		IdentitySecret: result.IdentitySecret,
+		Secret1:        result.Secret1,
+		Status:         result.Status,
+		DeviceID:       deviceID,
+		FullyEnrolled:  false,
+		Session:        *session,
+	}
+
+	confirmType := result.ConfirmType
+	if confirmType == 0 {
+		confirmType = ActivationSMS
+	}
+
+	return account, confirmType, nil
+}
+
+// FinalizeAddAuthenticator completes enrollment with the activation code
+func (c *Client) FinalizeAddAuthenticator(account *manifest.SteamGuardAccount, activationCode string) error {
+	for attempt := 0; attempt < 30; attempt++ {
+		code, err := account.GenerateCode()
+		if err != nil {
+			return err
+		}
+
+		var result struct {
+			Success  bool `json:"success"`
+			WantMore bool `json:"want_more"`
+			Status   int  `json:"status"`
+		}
+
+		params := url.Values{}
+		params.Set("steamid", account.Session.SteamID)
+		params.Set("authenticator_code", code)
+		params.Set("authenticator_time", strconv.FormatInt(time.Now().Unix(), 10))
+		params.Set("activation_code", activationCode)
+		params.Set("validate_sms_code", "1")
+		if err := c.callService(http.MethodPost, "ITwoFactorService/FinalizeAddAuthenticator", account.Session.AccessToken, params, &result); err != nil {
+			return fmt.Errorf("failed to finalize authenticator: %w", err)
+		}
+
+		if result.Status == 89 {
+			return ErrBadActivationCode
+		}
+		if result.WantMore {
+			// Steam wants codes from several periods, wait for the next one
+			time.Sleep(time.Duration(30-time.Now().Unix()%30) * time.Second)
+			continue
+		}
+		if !result.Success {
+			return fmt.Errorf("failed to finalize authenticator: status %d", result.Status)
+		}
+
+		account.FullyEnrolled = true
+		return nil
+	}
+
+	return fmt.Errorf("failed to finalize authenticator: too many attempts")
+}
+
+// SetAccountPhoneNumber attaches a phone number to the account
+// and returns the address of the confirmation email Steam sent
+func (c *Client) SetAccountPhoneNumber(session *manifest.SessionData, phoneNumber, countryCode string) (string, error) {
+	var result struct {
+		ConfirmationEmail string `json:"confirmation_email_address"`
+	}
+
+	params := url.Values{}
+	params.Set("phone_number", phoneNumber)
+	params.Set("phone_country_code", countryCode)
+	if err := c.callService(http.MethodPost, "IPhoneService/SetAccountPhoneNumber", session.AccessToken, params, &result); err != nil {
+		return "", fmt.Errorf("failed to set phone number: %w", err)
+	}
+
+	return result.ConfirmationEmail, nil
+}
+
+// WaitForPhoneEmailConfirmation waits until the phone confirmation email link is clicked
+func (c *Client) WaitForPhoneEmailConfirmation(session *manifest.SessionData, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		var result struct {
+			Awaiting    bool `json:"awaiting_email_confirmation"`
+			SecondsWait int  `json:"seconds_to_wait"`
+		}
+		if err := c.callService(http.MethodPost, "IPhoneService/IsAccountWaitingForEmailConfirmation", session.AccessToken, url.Values{}, &result); err != nil {
+			return fmt.Errorf("failed to check email confirmation: %w", err)
+		}
+		if !result.Awaiting {
+			return nil
+		}
+
+		wait := time.Duration(result.SecondsWait) * time.Second
+		if wait <= 0 {
+			wait = 5 * time.Second
+		}
+		time.Sleep(wait)
+	}
+
+	return fmt.Errorf("timed out waiting for phone email confirmation")
+}
+
+// SendPhoneVerificationCode asks Steam to send an SMS code to the attached phone
+func (c *Client) SendPhoneVerificationCode(session *manifest.SessionData) error {
+	params := url.Values{}
+	params.Set("language", "0")
+	if err := c.callService(http.MethodPost, "IPhoneService/SendPhoneVerificationCode", session.AccessToken, params, nil); err != nil {
+		return fmt.Errorf("failed to send SMS code: %w", err)
+	}
+	return nil
+}
+
+// RemoveAuthenticator revokes the account's authenticator with its revocation code
+func (c *Client) RemoveAuthenticator(account *manifest.SteamGuardAccount) error {
+	if account.RevocationCode == "" {
+		return fmt.Errorf("revocation code is missing")
+	}
+
+	var result struct {
+		Success           bool `json:"success"`
+		AttemptsRemaining int  `json:"revocation_attempts_remaining"`
+	}
+
+	params := url.Values{}
+	params.Set("steamid", account.Session.SteamID)
+	params.Set("revocation_code", account.RevocationCode)
+	params.Set("revocation_reason", "1")
+	params.Set("steamguard_scheme", "1")
+	if err := c.callService(http.MethodPost, "ITwoFactorService/RemoveAuthenticator", account.Session.AccessToken, params, &result); err != nil {
+		return fmt.Errorf("failed to remove authenticator: %w", err)
+	}
+
+	if !result.Success {
+		return fmt.Errorf("failed to remove authenticator: %d revocation attempts remaining", result.AttemptsRemaining)
+	}
+
+	return nil
+}
+
+// QueryTime returns Steam's server time
+func (c *Client) QueryTime() (time.Time, error) {
+	var result struct {
+		ServerTime string `json:"server_time"`
+	}
+	if err := c.callService(http.MethodPost, "ITwoFactorService/QueryTime", "", url.Values{}, &result); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query server time: %w", err)
+	}
+
+	serverTime, err := strconv.ParseInt(result.ServerTime, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid server time %q", result.ServerTime)
+	}
+	return time.Unix(serverTime, 0), nil
+}
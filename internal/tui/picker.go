@@ -0,0 +1,283 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Action what to do with the picked items
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionAccept
+	ActionReject
+)
+
+// Item one entry of the picker list
+type Item struct {
+	Title string
+	Lines []string
+}
+
+// Picker lets the user toggle items and choose an action for them
+type Picker struct {
+	Items []Item
+	// Details returns the detail text of an item
+	Details func(index int) (string, error)
+	// Prompt reads a line for the numbered fallback
+	Prompt func(label string) (string, error)
+
+	cursor   int
+	selected map[int]bool
+}
+
+// IsTerminal checks if stdin and stdout are terminals capable of the interactive list
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Run shows the keyboard-driven list, falling back to numbered prompts
+// when stdin or stdout is not a terminal. Returns the action and the
+// indexes of the selected items in ascending order.
+func (p *Picker) Run() (Action, []int, error) {
+	p.selected = make(map[int]bool)
+	if !IsTerminal() {
+		return p.runPrompt()
+	}
+	return p.runInteractive()
+}
+
+// runInteractive runs the list in raw terminal mode
+func (p *Picker) runInteractive() (Action, []int, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return p.runPrompt()
+	}
+	defer term.Restore(fd, state)
+	defer fmt.Print("\x1b[?25h")
+
+	fmt.Print("\x1b[?25l")
+	buf := make([]byte, 8)
+	for {
+		p.render()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return ActionNone, nil, err
+		}
+
+		switch key := string(buf[:n]); key {
+		case "\x1b[A", "k":
+			if p.cursor > 0 {
+				p.cursor--
+			}
+		case "\x1b[B", "j":
+			if p.cursor < len(p.Items)-1 {
+				p.cursor++
+			}
+		case " ", "x":
+			p.selected[p.cursor] = !p.selected[p.cursor]
+		case "A":
+			all := len(p.selectedIndexes()) < len(p.Items)
+			for i := range p.Items {
+				p.selected[i] = all
+			}
+		case "\r", "\n", "d":
+			p.showDetails(p.cursor)
+			os.Stdin.Read(buf)
+		case "a", "r":
+			indexes := p.selectedIndexes()
+			if len(indexes) == 0 {
+				continue
+			}
+			p.clear()
+			if key == "a" {
+				return ActionAccept, indexes, nil
+			}
+			return ActionReject, indexes, nil
+		case "q", "\x1b", "\x03":
+			p.clear()
+			return ActionNone, nil, nil
+		}
+	}
+}
+
+// render draws the list
+func (p *Picker) render() {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	b.WriteString("↑/↓ move  space toggle  A all  enter details  a accept  r reject  q quit\r\n\r\n")
+
+	for i, item := range p.Items {
+		cursor := "  "
+		if i == p.cursor {
+			cursor = "> "
+		}
+		mark := "[ ]"
+		if p.selected[i] {
+			mark = "[x]"
+		}
+		fmt.Fprintf(&b, "%s%s %s\r\n", cursor, mark, item.Title)
+		for _, line := range item.Lines {
+			fmt.Fprintf(&b, "        %s\r\n", line)
+		}
+	}
+
+	fmt.Fprintf(&b, "\r\nSelected: %d of %d\r\n", len(p.selectedIndexes()), len(p.Items))
+	fmt.Print(b.String())
+}
+
+// showDetails draws the details of an item until a key is pressed
+func (p *Picker) showDetails(index int) {
+	text := strings.Join(p.Items[index].Lines, "\n")
+	if p.Details != nil {
+		details, err := p.Details(index)
+		if err != nil {
+			text = fmt.Sprintf("Failed to load details: %v", err)
+		} else {
+			text = details
+		}
+	}
+
+	fmt.Print("\x1b[H\x1b[2J")
+	fmt.Printf("%s\r\n\r\n", p.Items[index].Title)
+	fmt.Print(strings.ReplaceAll(text, "\n", "\r\n"))
+	fmt.Print("\r\n\r\nPress any key to go back")
+}
+
+// clear clears the screen
+func (p *Picker) clear() {
+	fmt.Print("\x1b[H\x1b[2J")
+}
+
+// selectedIndexes returns selected indexes in ascending order
+func (p *Picker) selectedIndexes() []int {
+	var indexes []int
+	for i, ok := range p.selected {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// runPrompt asks for the selection with numbered prompts
+func (p *Picker) runPrompt() (Action, []int, error) {
+	for i, item := range p.Items {
+		fmt.Printf("[%d] %s\n", i+1, item.Title)
+		for _, line := range item.Lines {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	fmt.Println()
+
+	for {
+		answer, err := p.Prompt("Select confirmations (e.g. 1,3-5 or all), 'd N' for details, empty to quit: ")
+		if err != nil {
+			return ActionNone, nil, err
+		}
+		if answer == "" {
+			return ActionNone, nil, nil
+		}
+
+		if strings.HasPrefix(answer, "d ") {
+			index, err := strconv.Atoi(strings.TrimSpace(answer[2:]))
+			if err != nil || index < 1 || index > len(p.Items) {
+				fmt.Println("Invalid number.")
+				continue
+			}
+			p.printDetails(index - 1)
+			continue
+		}
+
+		indexes, err := ParseSelection(answer, len(p.Items))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		action, err := p.Prompt(fmt.Sprintf("%d selected. [a]ccept, [r]eject or [q]uit: ", len(indexes)))
+		if err != nil {
+			return ActionNone, nil, err
+		}
+		switch strings.ToLower(action) {
+		case "a", "accept":
+			return ActionAccept, indexes, nil
+		case "r", "reject":
+			return ActionReject, indexes, nil
+		default:
+			return ActionNone, nil, nil
+		}
+	}
+}
+
+// printDetails prints the details of an item in prompt mode
+func (p *Picker) printDetails(index int) {
+	fmt.Printf("\n%s\n", p.Items[index].Title)
+	if p.Details == nil {
+		fmt.Println(strings.Join(p.Items[index].Lines, "\n"))
+		return
+	}
+
+	details, err := p.Details(index)
+	if err != nil {
+		fmt.Printf("Failed to load details: %v\n", err)
+		return
+	}
+	fmt.Printf("%s\n\n", details)
+}
+
+// ParseSelection parses "1,3-5" or "all" into 0-based indexes
+func ParseSelection(input string, count int) ([]int, error) {
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input == "all" || input == "*" {
+		indexes := make([]int, count)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	seen := make(map[int]bool)
+	for _, part := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = part[:i], part[i+1:]
+		}
+
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", part)
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", part)
+		}
+		if start < 1 || end > count || start > end {
+			return nil, fmt.Errorf("selection %q is out of range 1-%d", part, count)
+		}
+
+		for i := start; i <= end; i++ {
+			seen[i-1] = true
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("nothing selected")
+	}
+
+	indexes := make([]int, 0, len(seen))
+	for i := range seen {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}